@@ -3,9 +3,21 @@
 // A minimal HTTP server that receives webhook payloads from the CertWatch CLI,
 // verifies HMAC-SHA256 signatures, and pretty-prints the results.
 //
+// Both signature schemes are accepted:
+//
+//	sha256=<hex>          HMAC of the raw body (default)
+//	t=<unix>,v1=<hex>     HMAC of "<unix>.<body>" (-signature timestamped)
+//
+// During a secret rotation (-next-secret) the header may carry several
+// signatures, e.g. "sha256=<a>,sha256=<b>"; any match is accepted.
+//
+// Timestamped signatures older than -tolerance are rejected, which protects
+// against replayed requests. -tolerance 0 disables the check, as in the CLI's
+// listen command.
+//
 // Usage:
 //
-//	go run receiver.go -secret <secret> [-port <port>]
+//	go run receiver.go -secret <secret> [-port <port>] [-tolerance <duration>]
 //
 // Example:
 //
@@ -28,6 +40,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -37,17 +50,61 @@ import (
 // HMAC verification
 // ---------------------------------------------------------------------------
 
-func verifySignature(body []byte, signatureHeader, secret string) bool {
-	if !strings.HasPrefix(signatureHeader, "sha256=") {
+func verifySignature(body []byte, signatureHeader, secret string, tolerance time.Duration) bool {
+	if strings.HasPrefix(signatureHeader, "t=") {
+		return verifyTimestampedSignature(body, signatureHeader, secret, tolerance)
+	}
+
+	// During a secret rotation the header carries one signature per secret:
+	// "sha256=<a>,sha256=<b>". Accept the request if any of them matches.
+	expected := sign(body, secret)
+	for _, part := range strings.Split(signatureHeader, ",") {
+		provided, ok := strings.CutPrefix(part, "sha256=")
+		if ok && hmac.Equal([]byte(expected), []byte(provided)) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyTimestampedSignature checks a "t=<unix>,v1=<hex>" header. The HMAC
+// covers "<unix>.<body>", so the timestamp cannot be altered without
+// invalidating the signature, and old timestamps are rejected. A tolerance of
+// 0 or less disables the age check.
+func verifyTimestampedSignature(body []byte, signatureHeader, secret string, tolerance time.Duration) bool {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(t, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
 		return false
 	}
-	provided := signatureHeader[7:] // strip "sha256="
 
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	expected := hex.EncodeToString(mac.Sum(nil))
+	expected := sign([]byte(timestamp+"."+string(body)), secret)
+	for _, provided := range signatures {
+		if hmac.Equal([]byte(expected), []byte(provided)) {
+			return true
+		}
+	}
+	return false
+}
 
-	return hmac.Equal([]byte(expected), []byte(provided))
+func sign(data []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // ---------------------------------------------------------------------------
@@ -81,18 +138,21 @@ type webhookPayload struct {
 func main() {
 	secretFlag := flag.String("secret", "", "The same secret passed to the CLI (-secret)")
 	portFlag := flag.String("port", "3000", "Port to listen on (default: 3000)")
+	toleranceFlag := flag.Duration("tolerance", 5*time.Minute, "Maximum age of timestamped signatures; 0 disables the check (default: 5m)")
 	flag.Parse()
 
 	if *secretFlag == "" {
-		fmt.Fprintln(os.Stderr, "Usage: go run receiver.go -secret <secret> [-port <port>]")
+		fmt.Fprintln(os.Stderr, "Usage: go run receiver.go -secret <secret> [-port <port>] [-tolerance <duration>]")
 		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "  -secret <secret>  The same secret passed to the CLI (-secret)")
-		fmt.Fprintln(os.Stderr, "  -port <port>      Port to listen on (default: 3000)")
+		fmt.Fprintln(os.Stderr, "  -secret <secret>        The same secret passed to the CLI (-secret)")
+		fmt.Fprintln(os.Stderr, "  -port <port>            Port to listen on (default: 3000)")
+		fmt.Fprintln(os.Stderr, "  -tolerance <duration>   Maximum age of timestamped signatures; 0 disables the check (default: 5m)")
 		os.Exit(1)
 	}
 
 	secret := *secretFlag
 	port := *portFlag
+	tolerance := *toleranceFlag
 
 	var count atomic.Int64
 
@@ -119,7 +179,7 @@ func main() {
 		defer r.Body.Close()
 
 		sig := r.Header.Get("X-CertWatch-Signature")
-		verified := verifySignature(body, sig, secret)
+		verified := verifySignature(body, sig, secret, tolerance)
 
 		var payload webhookPayload
 		json.Unmarshal(body, &payload)
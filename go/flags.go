@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/certwatch-app/certwatch-webhook-cli/go/internal"
+)
+
+// deliveryFlags holds the flags shared by every command that delivers
+// payloads to a target URL.
+type deliveryFlags struct {
+	maxAttempts   *int
+	retryDelay    *time.Duration
+	retryMaxDelay *time.Duration
+	concurrency   *int
+	queueSize     *int
+	unordered     *bool
+	signature     *string
+	profile       *string
+	nextSecret    *string
+	rotation      *string
+
+	chaos             *string
+	chaosSeed         *uint64
+	chaosSlowDuration *time.Duration
+
+	expectStatus  *string
+	maxLatency    *time.Duration
+	expectHeaders stringList
+	expectBody    stringList
+
+	headers     stringList
+	basicAuth   *string
+	bearerToken *string
+
+	caCert        *string
+	clientCert    *string
+	clientKey     *string
+	tlsServerName *string
+	tlsMinVersion *string
+	insecure      *bool
+
+	proxy   *string
+	noProxy *string
+
+	timeout               *time.Duration
+	connectTimeout        *time.Duration
+	tlsHandshakeTimeout   *time.Duration
+	responseHeaderTimeout *time.Duration
+	keepAlive             *bool
+	maxIdleConns          *int
+
+	transformFile *string
+
+	cloudEvents *string
+	ceSource    *string
+	ceBatchSize *int
+	ceBatchWait *time.Duration
+}
+
+// stringList is a flag.Value that collects every occurrence of a repeatable
+// flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// resolveTargets combines the -url values with the targets in the -targets
+// file, if one is given.
+func resolveTargets(urls []string, targetsFile string) ([]internal.Target, error) {
+	var fileTargets []internal.Target
+	if targetsFile != "" {
+		var err error
+		if fileTargets, err = internal.LoadTargets(targetsFile); err != nil {
+			return nil, err
+		}
+	}
+	return internal.ResolveTargets(urls, fileTargets), nil
+}
+
+// addDeliveryFlags registers the shared delivery flags on fs.
+func addDeliveryFlags(fs *flag.FlagSet) *deliveryFlags {
+	f := &deliveryFlags{
+		maxAttempts:   fs.Int("max-attempts", 1, "Maximum delivery attempts per payload (1 disables retries)"),
+		retryDelay:    fs.Duration("retry-delay", time.Second, "Base delay for exponential retry backoff"),
+		retryMaxDelay: fs.Duration("retry-max-delay", 30*time.Second, "Maximum delay between retry attempts"),
+		concurrency:   fs.Int("concurrency", 1, "Number of concurrent delivery workers"),
+		queueSize:     fs.Int("queue-size", 1000, "Payloads buffered between the source and delivery workers"),
+		unordered:     fs.Bool("unordered", false, "Report deliveries as they complete instead of in source order"),
+		signature:     fs.String("signature", internal.SignatureSchemeBody, "Signature scheme: body (sha256=<hmac>) or timestamped (t=<unix>,v1=<hmac>)"),
+		profile:       fs.String("profile", internal.HeaderProfileCertWatch, "Webhook headers to send: certwatch, standard-webhooks, or both"),
+		nextSecret:    fs.String("next-secret", "", "New signing secret being rotated in"),
+		rotation:      fs.String("rotation", internal.RotationBoth, "Secrets to sign with when -next-secret is set: old, new, or both"),
+
+		chaos:             fs.String("chaos", "", "Inject faults with the given probabilities, e.g. wrong-signature=0.1,duplicate=0.05"),
+		chaosSeed:         fs.Uint64("chaos-seed", 0, "Random seed for -chaos (0 picks one; the seed is printed so runs can be reproduced)"),
+		chaosSlowDuration: fs.Duration("chaos-slow-duration", 15*time.Second, "Time taken to write a slowloris body"),
+
+		expectStatus: fs.String("expect-status", "", "Comma-separated status codes that count as success (default: any 2xx)"),
+		maxLatency:   fs.Duration("max-latency", 0, "Fail deliveries slower than this (0 disables the check)"),
+
+		basicAuth:   fs.String("basic-auth", "", "Send HTTP basic auth with every delivery, as 'user:password' ($VARS are expanded)"),
+		bearerToken: fs.String("bearer-token", "", "Send 'Authorization: Bearer <token>' with every delivery ($VARS are expanded)"),
+
+		caCert:        fs.String("ca-cert", "", "PEM bundle of additional CAs to trust for HTTPS targets"),
+		clientCert:    fs.String("client-cert", "", "PEM client certificate for mutual TLS (requires -client-key)"),
+		clientKey:     fs.String("client-key", "", "PEM private key for -client-cert"),
+		tlsServerName: fs.String("tls-server-name", "", "Override the TLS server name (SNI) sent to and verified for HTTPS targets"),
+		tlsMinVersion: fs.String("tls-min-version", "", "Minimum TLS version: 1.0, 1.1, 1.2, or 1.3 (default: Go's default, 1.2)"),
+		insecure:      fs.Bool("insecure", false, "Skip TLS certificate verification for HTTPS targets"),
+
+		proxy:   fs.String("proxy", "", "Proxy for deliveries: http://, https://, or socks5:// URL, or 'direct' (default: HTTP_PROXY/HTTPS_PROXY)"),
+		noProxy: fs.String("no-proxy", "", "Comma-separated hosts, domains, IPs, and CIDRs delivered to without -proxy"),
+
+		timeout:               fs.Duration("timeout", 10*time.Second, "Overall limit for each delivery attempt, including reading the response (0 disables)"),
+		connectTimeout:        fs.Duration("connect-timeout", 30*time.Second, "Limit for connecting to the target (0 disables)"),
+		tlsHandshakeTimeout:   fs.Duration("tls-handshake-timeout", 10*time.Second, "Limit for the TLS handshake with HTTPS targets (0 disables)"),
+		responseHeaderTimeout: fs.Duration("response-header-timeout", 0, "Limit for receiving response headers once the request is sent (0 disables)"),
+		keepAlive:             fs.Bool("keep-alive", true, "Reuse connections between deliveries (-keep-alive=false opens one per delivery)"),
+		maxIdleConns:          fs.Int("max-idle-conns", 100, "Idle keep-alive connections kept open per target host"),
+
+		transformFile: fs.String("transform", "", "Go text/template file that reshapes each payload into the JSON body to sign and deliver"),
+
+		cloudEvents: fs.String("cloudevents", "", "Deliver CloudEvents: binary, structured, or batch (default: CertWatch webhooks)"),
+		ceSource:    fs.String("ce-source", internal.DefaultCloudEventsSource, "CloudEvents source attribute"),
+		ceBatchSize: fs.Int("ce-batch-size", 10, "Events per request when -cloudevents is batch"),
+		ceBatchWait: fs.Duration("ce-batch-wait", time.Second, "Longest a partial batch waits for more events before it is sent (0 waits for a full batch)"),
+	}
+	fs.Var(&f.expectHeaders, "expect-header", "Require a response header, as 'Name' or 'Name: value' (repeatable)")
+	fs.Var(&f.expectBody, "expect-body", "Require a JSON response body match, as 'path' or 'path=value', e.g. '$.received=true' (repeatable)")
+	fs.Var(&f.headers, "header", "Send an extra request header with every delivery, as 'Name: value' ($VARS are expanded; repeatable)")
+	return f
+}
+
+// options checks the delivery flags for invalid values and converts them
+// into internal.DeliveryOptions.
+func (f *deliveryFlags) options() (internal.DeliveryOptions, error) {
+	if *f.maxAttempts < 1 {
+		return internal.DeliveryOptions{}, errors.New("-max-attempts must be at least 1")
+	}
+	if *f.concurrency < 1 {
+		return internal.DeliveryOptions{}, errors.New("-concurrency must be at least 1")
+	}
+	if *f.maxIdleConns < 1 {
+		return internal.DeliveryOptions{}, errors.New("-max-idle-conns must be at least 1")
+	}
+	if err := validateSignatureScheme(*f.signature); err != nil {
+		return internal.DeliveryOptions{}, err
+	}
+	if err := validateProfile(*f.profile); err != nil {
+		return internal.DeliveryOptions{}, err
+	}
+	switch *f.rotation {
+	case internal.RotationOld, internal.RotationBoth:
+	case internal.RotationNew:
+		if *f.nextSecret == "" {
+			return internal.DeliveryOptions{}, errors.New("-rotation new requires -next-secret")
+		}
+	default:
+		return internal.DeliveryOptions{}, fmt.Errorf("unknown -rotation %q (want old, new, or both)", *f.rotation)
+	}
+	faults, err := internal.ParseChaosSpec(*f.chaos)
+	if err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-chaos: %w", err)
+	}
+	if faults[internal.FaultStaleTimestamp] > 0 && *f.signature != internal.SignatureSchemeTimestamped && *f.profile == internal.HeaderProfileCertWatch {
+		// Neither the body signature nor the CertWatch headers sign the
+		// timestamp, so a stale one would go undetected by design.
+		return internal.DeliveryOptions{}, errors.New("-chaos stale-timestamp requires -signature timestamped or a -profile that includes standard-webhooks")
+	}
+	seed := *f.chaosSeed
+	if seed == 0 && len(faults) > 0 {
+		seed = rand.Uint64()
+	}
+
+	statuses, err := internal.ParseStatusList(*f.expectStatus)
+	if err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-expect-status: %w", err)
+	}
+	expect := internal.Expectations{Statuses: statuses, MaxLatency: *f.maxLatency}
+	for _, h := range f.expectHeaders {
+		e, err := internal.ParseHeaderExpectation(h)
+		if err != nil {
+			return internal.DeliveryOptions{}, fmt.Errorf("-expect-header: %w", err)
+		}
+		expect.Headers = append(expect.Headers, e)
+	}
+	for _, b := range f.expectBody {
+		e, err := internal.ParseBodyExpectation(b)
+		if err != nil {
+			return internal.DeliveryOptions{}, fmt.Errorf("-expect-body: %w", err)
+		}
+		expect.Body = append(expect.Body, e)
+	}
+
+	header := make(http.Header)
+	for _, h := range f.headers {
+		name, value, err := internal.ParseRequestHeader(h)
+		if err != nil {
+			return internal.DeliveryOptions{}, fmt.Errorf("-header: %w", err)
+		}
+		if value, err = internal.ExpandEnv(value); err != nil {
+			return internal.DeliveryOptions{}, fmt.Errorf("-header %s: %w", name, err)
+		}
+		header.Set(name, value)
+	}
+	if *f.basicAuth != "" && *f.bearerToken != "" {
+		return internal.DeliveryOptions{}, errors.New("-basic-auth and -bearer-token cannot be combined")
+	}
+	if *f.basicAuth, err = internal.ExpandEnv(*f.basicAuth); err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-basic-auth: %w", err)
+	}
+	if *f.basicAuth != "" && !strings.Contains(*f.basicAuth, ":") {
+		return internal.DeliveryOptions{}, errors.New("-basic-auth must be 'user:password'")
+	}
+	if *f.bearerToken, err = internal.ExpandEnv(*f.bearerToken); err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-bearer-token: %w", err)
+	}
+
+	if (*f.clientCert == "") != (*f.clientKey == "") {
+		return internal.DeliveryOptions{}, errors.New("-client-cert and -client-key must be given together")
+	}
+	if err := internal.ValidateTLSVersion(*f.tlsMinVersion); err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-tls-min-version: %w", err)
+	}
+
+	if *f.proxy, err = internal.ExpandEnv(*f.proxy); err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-proxy: %w", err)
+	}
+	if err := internal.ValidateProxy(internal.ProxyOptions{URL: *f.proxy}); err != nil {
+		return internal.DeliveryOptions{}, fmt.Errorf("-proxy: %w", err)
+	}
+
+	if err := validateCloudEventsMode(*f.cloudEvents); err != nil {
+		return internal.DeliveryOptions{}, err
+	}
+	if *f.cloudEvents == internal.CloudEventsBatch {
+		if *f.ceBatchSize < 1 {
+			return internal.DeliveryOptions{}, errors.New("-ce-batch-size must be at least 1")
+		}
+		if *f.ceBatchWait < 0 {
+			return internal.DeliveryOptions{}, errors.New("-ce-batch-wait must not be negative")
+		}
+		if len(faults) > 0 {
+			return internal.DeliveryOptions{}, errors.New("-chaos cannot be combined with -cloudevents batch")
+		}
+	}
+	if *f.ceSource == "" {
+		return internal.DeliveryOptions{}, errors.New("-ce-source must not be empty")
+	}
+
+	var transform *internal.PayloadTransform
+	if *f.transformFile != "" {
+		if transform, err = internal.LoadTransform(*f.transformFile); err != nil {
+			return internal.DeliveryOptions{}, fmt.Errorf("-transform: %w", err)
+		}
+	}
+
+	opts := internal.DeliveryOptions{
+		MaxAttempts:   *f.maxAttempts,
+		RetryDelay:    *f.retryDelay,
+		RetryMaxDelay: *f.retryMaxDelay,
+		Concurrency:   *f.concurrency,
+		QueueSize:     *f.queueSize,
+		Unordered:     *f.unordered,
+
+		SignatureScheme: *f.signature,
+		Profile:         *f.profile,
+
+		NextSecret: *f.nextSecret,
+		Rotation:   *f.rotation,
+
+		Chaos: internal.ChaosOptions{
+			Faults:       faults,
+			Seed:         seed,
+			SlowDuration: *f.chaosSlowDuration,
+		},
+
+		Expect: expect,
+
+		Headers:     header,
+		BasicAuth:   *f.basicAuth,
+		BearerToken: *f.bearerToken,
+
+		TLS: internal.TLSOptions{
+			CAFile:     *f.caCert,
+			CertFile:   *f.clientCert,
+			KeyFile:    *f.clientKey,
+			ServerName: *f.tlsServerName,
+			MinVersion: *f.tlsMinVersion,
+			Insecure:   *f.insecure,
+		},
+
+		Proxy: internal.ProxyOptions{URL: *f.proxy, NoProxy: *f.noProxy},
+
+		Client: internal.ClientOptions{
+			Timeout:               *f.timeout,
+			ConnectTimeout:        *f.connectTimeout,
+			TLSHandshakeTimeout:   *f.tlsHandshakeTimeout,
+			ResponseHeaderTimeout: *f.responseHeaderTimeout,
+			DisableKeepAlives:     !*f.keepAlive,
+			MaxIdleConns:          *f.maxIdleConns,
+		},
+
+		Transform: transform,
+
+		CloudEvents: internal.CloudEventsOptions{
+			Mode:      *f.cloudEvents,
+			Source:    *f.ceSource,
+			BatchSize: *f.ceBatchSize,
+			BatchWait: *f.ceBatchWait,
+		},
+	}
+	return opts, nil
+}
+
+// validateSignatureScheme checks the value of a -signature flag.
+func validateSignatureScheme(scheme string) error {
+	switch scheme {
+	case internal.SignatureSchemeBody, internal.SignatureSchemeTimestamped:
+		return nil
+	}
+	return fmt.Errorf("unknown -signature %q (want body or timestamped)", scheme)
+}
+
+// validateProfile checks the value of a -profile flag.
+func validateProfile(profile string) error {
+	switch profile {
+	case internal.HeaderProfileCertWatch, internal.HeaderProfileStandardWebhooks, internal.HeaderProfileBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown -profile %q (want certwatch, standard-webhooks, or both)", profile)
+}
+
+// validateCloudEventsMode checks the value of a -cloudevents flag.
+func validateCloudEventsMode(mode string) error {
+	switch mode {
+	case "", internal.CloudEventsBinary, internal.CloudEventsStructured, internal.CloudEventsBatch:
+		return nil
+	}
+	return fmt.Errorf("unknown -cloudevents %q (want binary, structured, or batch)", mode)
+}
@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chaos faults that can be injected into deliveries.
+const (
+	FaultWrongSignature = "wrong-signature" // Signed with a random secret.
+	FaultMissingHeaders = "missing-headers" // Signature, event ID, and timestamp headers omitted.
+	FaultStaleTimestamp = "stale-timestamp" // Signed as if sent chaosStaleAge ago.
+	FaultBodyMutation   = "body-mutation"   // Body truncated after signing.
+	FaultDuplicate      = "duplicate"       // Valid payload delivered a second time.
+	FaultOutOfOrder     = "out-of-order"    // Valid payload delivered after its successor.
+	FaultSlowloris      = "slowloris"       // Valid payload whose body is written one byte at a time.
+)
+
+// chaosFaults lists every fault in the order they are drawn and reported.
+var chaosFaults = []string{
+	FaultWrongSignature,
+	FaultMissingHeaders,
+	FaultStaleTimestamp,
+	FaultBodyMutation,
+	FaultDuplicate,
+	FaultOutOfOrder,
+	FaultSlowloris,
+}
+
+// chaosStaleAge is how far in the past a stale-timestamp delivery is signed.
+// It is well outside the usual five-minute replay window.
+const chaosStaleAge = time.Hour
+
+// faultExpectsAccept reports whether a correct endpoint should accept a
+// delivery with the given fault. Out-of-order deliveries are valid requests;
+// every other fault should be rejected.
+func faultExpectsAccept(fault string) bool {
+	return fault == FaultOutOfOrder
+}
+
+// ParseChaosSpec parses a comma-separated list of fault=probability pairs,
+// such as "wrong-signature=0.1,duplicate=0.05". Each payload receives at
+// most one fault, so the probabilities must sum to at most 1.
+func ParseChaosSpec(spec string) (map[string]float64, error) {
+	faults := make(map[string]float64)
+	var total float64
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chaos fault %q (want fault=probability)", part)
+		}
+		if !isChaosFault(name) {
+			return nil, fmt.Errorf("unknown chaos fault %q (want one of %s)", name, strings.Join(chaosFaults, ", "))
+		}
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil || p < 0 || p > 1 {
+			return nil, fmt.Errorf("invalid probability %q for chaos fault %s (want 0 to 1)", value, name)
+		}
+		total += p - faults[name]
+		faults[name] = p
+	}
+
+	if total > 1 {
+		return nil, fmt.Errorf("chaos fault probabilities sum to %g (must be at most 1)", total)
+	}
+	return faults, nil
+}
+
+// isChaosFault reports whether name is a known fault.
+func isChaosFault(name string) bool {
+	for _, f := range chaosFaults {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// chaosInjector decides which fault, if any, to inject into each payload.
+// It is only used from the goroutine that submits payloads to the pool.
+type chaosInjector struct {
+	opts ChaosOptions
+	rng  *rand.Rand
+}
+
+// enabled reports whether any fault has a non-zero probability.
+func (o ChaosOptions) enabled() bool {
+	for _, p := range o.Faults {
+		if p > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// newChaosInjector returns an injector for opts, or nil if chaos mode is off.
+func newChaosInjector(opts ChaosOptions) *chaosInjector {
+	if !opts.enabled() {
+		return nil
+	}
+	return &chaosInjector{opts: opts, rng: rand.New(rand.NewPCG(opts.Seed, opts.Seed))}
+}
+
+// draw picks the fault for the next payload, or "" to deliver it normally.
+func (c *chaosInjector) draw() string {
+	r := c.rng.Float64()
+	for _, fault := range chaosFaults {
+		p := c.opts.Faults[fault]
+		if r < p {
+			return fault
+		}
+		r -= p
+	}
+	return ""
+}
+
+// deliverFault makes a single delivery attempt of payload with fault
+// injected. Faulted deliveries are never retried: a rejection is the
+// expected outcome for most of them.
+func deliverFault(ctx context.Context, payload WebhookPayload, cfg DeliveryConfig, index int, fault string, slowDuration time.Duration) DeliveryResult {
+	var t requestTamper
+
+	switch fault {
+	case FaultWrongSignature:
+		cfg.Secret = randomHex(32)
+		cfg.NextSecret = ""
+	case FaultMissingHeaders:
+		t.headers = func(headers []headerField) []headerField {
+			kept := headers[:0]
+			for _, h := range headers {
+				if !isSigningHeader(h.Name) {
+					kept = append(kept, h)
+				}
+			}
+			return kept
+		}
+	case FaultStaleTimestamp:
+		t.signedAt = time.Now().Add(-chaosStaleAge)
+		t.headers = func(headers []headerField) []headerField {
+			for i, h := range headers {
+				if h.Name == "X-CertWatch-Timestamp" {
+					headers[i].Value = t.signedAt.UTC().Format(time.RFC3339)
+				}
+			}
+			return headers
+		}
+	case FaultBodyMutation:
+		t.body = func(body []byte) (io.Reader, int64) {
+			truncated := body[:len(body)/2]
+			return strings.NewReader(string(truncated)), int64(len(truncated))
+		}
+	case FaultSlowloris:
+		t.body = func(body []byte) (io.Reader, int64) {
+			interval := slowDuration / time.Duration(max(len(body), 1))
+			return &slowReader{ctx: ctx, data: body, interval: interval}, int64(len(body))
+		}
+		// A one-byte write buffer makes every byte reach the wire as soon as
+		// it is read, instead of the whole body being flushed at the end.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if base, ok := cfg.client().Transport.(*http.Transport); ok {
+			transport = base.Clone()
+		}
+		transport.WriteBufferSize = 1
+		transport.DisableKeepAlives = true
+		timeout := cfg.client().Timeout
+		if timeout > 0 {
+			timeout += slowDuration
+		}
+		t.client = &http.Client{Timeout: timeout, Transport: transport}
+	}
+
+	result := sendPayload(ctx, payload, cfg, index, t)
+	result.Attempt = 1
+	result.Fault = fault
+	return result
+}
+
+// slowReader yields its data one byte per interval.
+type slowReader struct {
+	ctx      context.Context
+	data     []byte
+	interval time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := sleepContext(r.ctx, r.interval); err != nil {
+		return 0, err
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+// ChaosFaultStats summarizes how the endpoint handled one kind of fault.
+type ChaosFaultStats struct {
+	Fault       string  `json:"fault"`
+	Probability float64 `json:"probability"`
+	Expect      string  `json:"expect"` // "reject" or "accept".
+	Injected    int     `json:"injected"`
+	Rejected    int     `json:"rejected"` // Non-2xx responses (or, for slowloris, dropped connections).
+	Accepted    int     `json:"accepted"` // 2xx responses, regardless of other expectations.
+	Errors      int     `json:"errors"`   // Network errors.
+	Passed      bool    `json:"passed"`
+}
+
+// splitChaosResults separates faulted deliveries from normal ones and
+// summarizes the faulted deliveries per configured fault. It returns nil
+// stats when chaos mode is off.
+func splitChaosResults(results []DeliveryResult, opts ChaosOptions) ([]DeliveryResult, []ChaosFaultStats) {
+	if !opts.enabled() {
+		return results, nil
+	}
+
+	var normal []DeliveryResult
+	byFault := make(map[string]*ChaosFaultStats)
+	var stats []ChaosFaultStats
+	for _, fault := range chaosFaults {
+		if opts.Faults[fault] > 0 {
+			expect := "reject"
+			if faultExpectsAccept(fault) {
+				expect = "accept"
+			}
+			stats = append(stats, ChaosFaultStats{Fault: fault, Probability: opts.Faults[fault], Expect: expect})
+		}
+	}
+	for i := range stats {
+		byFault[stats[i].Fault] = &stats[i]
+	}
+
+	for _, r := range results {
+		s, ok := byFault[r.Fault]
+		if !ok {
+			normal = append(normal, r)
+			continue
+		}
+		s.Injected++
+		switch {
+		case isSuccessStatus(r.Status):
+			s.Accepted++
+		case r.Status != 0 || r.Fault == FaultSlowloris:
+			s.Rejected++
+		default:
+			s.Errors++
+		}
+	}
+
+	for i := range stats {
+		s := &stats[i]
+		if faultExpectsAccept(s.Fault) {
+			s.Passed = s.Rejected == 0 && s.Errors == 0
+		} else {
+			s.Passed = s.Accepted == 0
+		}
+	}
+
+	return normal, stats
+}
+
+// chaosHandledCorrectly reports whether a faulted delivery had the outcome
+// a correct endpoint would produce.
+func chaosHandledCorrectly(r DeliveryResult) bool {
+	if faultExpectsAccept(r.Fault) {
+		return isSuccessStatus(r.Status)
+	}
+	return !isSuccessStatus(r.Status) && (r.Status != 0 || r.Fault == FaultSlowloris)
+}
+
+// chaosLabel describes the configured faults for the banner, e.g.
+// "wrong-signature 10%, duplicate 5% (seed 42)".
+func chaosLabel(opts ChaosOptions) string {
+	var parts []string
+	for _, fault := range chaosFaults {
+		if p := opts.Faults[fault]; p > 0 {
+			parts = append(parts, fmt.Sprintf("%s %g%%", fault, p*100))
+		}
+	}
+	label := strings.Join(parts, ", ")
+	if opts.Seed != 0 {
+		label += fmt.Sprintf(" (seed %d)", opts.Seed)
+	}
+	return label
+}
+
+// isSuccessStatus reports whether status is a 2xx status.
+func isSuccessStatus(status int) bool {
+	return status >= 200 && status < 300
+}
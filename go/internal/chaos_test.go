@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseChaosSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]float64
+		wantErr string
+	}{
+		{spec: "", want: map[string]float64{}},
+		{spec: "duplicate=0.1", want: map[string]float64{FaultDuplicate: 0.1}},
+		{
+			spec: " wrong-signature=0.25 , stale-timestamp=0.5,",
+			want: map[string]float64{FaultWrongSignature: 0.25, FaultStaleTimestamp: 0.5},
+		},
+		{spec: "duplicate=0,slowloris=1", want: map[string]float64{FaultDuplicate: 0, FaultSlowloris: 1}},
+		// A repeated fault replaces the earlier probability instead of adding to it.
+		{spec: "duplicate=0.8,duplicate=0.5,out-of-order=0.5", want: map[string]float64{FaultDuplicate: 0.5, FaultOutOfOrder: 0.5}},
+
+		{spec: "duplicate", wantErr: "want fault=probability"},
+		{spec: "teleport=0.1", wantErr: "unknown chaos fault"},
+		{spec: "duplicate=lots", wantErr: "invalid probability"},
+		{spec: "duplicate=-0.1", wantErr: "invalid probability"},
+		{spec: "duplicate=1.5", wantErr: "invalid probability"},
+		{spec: "duplicate=0.6,body-mutation=0.6", wantErr: "sum to 1.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseChaosSpec(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseChaosSpec(%q) error = %v, want it to contain %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseChaosSpec(%q) error = %v", tt.spec, err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("ParseChaosSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
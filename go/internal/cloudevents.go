@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CloudEvents HTTP protocol binding modes.
+const (
+	// CloudEventsBinary sends the event data as the body and the event
+	// attributes as ce-* headers.
+	CloudEventsBinary = "binary"
+	// CloudEventsStructured sends the whole event as an
+	// application/cloudevents+json body.
+	CloudEventsStructured = "structured"
+	// CloudEventsBatch sends several structured events as a JSON array in an
+	// application/cloudevents-batch+json body.
+	CloudEventsBatch = "batch"
+)
+
+// DefaultCloudEventsSource is the CloudEvents source attribute used unless
+// another is configured.
+const DefaultCloudEventsSource = "https://certwatch.app"
+
+// cloudEventsSpecVersion is the CloudEvents specification version sent.
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEventsOptions configures delivery as CloudEvents. The zero value
+// delivers plain CertWatch webhooks.
+type CloudEventsOptions struct {
+	Mode      string // One of CloudEventsBinary, CloudEventsStructured, or CloudEventsBatch; empty disables CloudEvents.
+	Source    string // The source attribute of every event.
+	BatchSize int    // Events per request in CloudEventsBatch mode.
+
+	// BatchWait is the longest a partial batch waits for more events before
+	// it is sent anyway; zero waits until the batch is full or the run ends.
+	BatchWait time.Duration
+}
+
+// cloudEvent is a CloudEvent in the JSON event format.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// event returns the CloudEvent for payload carrying data. The event ID, type,
+// and time come from the payload envelope and the subject is the
+// certificate's common name.
+func (o CloudEventsOptions) event(payload WebhookPayload, data []byte) cloudEvent {
+	return cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              payload.EventID,
+		Source:          o.Source,
+		Type:            payload.Event,
+		Subject:         payload.Data.CommonName,
+		Time:            payload.Timestamp,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// contentType returns the Content-Type of requests in o.Mode.
+func (o CloudEventsOptions) contentType() string {
+	switch o.Mode {
+	case CloudEventsStructured:
+		return "application/cloudevents+json; charset=UTF-8"
+	case CloudEventsBatch:
+		return "application/cloudevents-batch+json; charset=UTF-8"
+	}
+	return "application/json"
+}
+
+// binaryHeaders returns the ce-* attribute headers for payload in binary
+// mode, or nil in any other mode.
+func (o CloudEventsOptions) binaryHeaders(payload WebhookPayload) []headerField {
+	if o.Mode != CloudEventsBinary {
+		return nil
+	}
+	event := o.event(payload, nil)
+	headers := []headerField{
+		{"ce-specversion", event.SpecVersion},
+		{"ce-id", event.ID},
+		{"ce-source", event.Source},
+		{"ce-type", event.Type},
+	}
+	if event.Subject != "" {
+		headers = append(headers, headerField{"ce-subject", event.Subject})
+	}
+	if event.Time != "" {
+		headers = append(headers, headerField{"ce-time", event.Time})
+	}
+	return headers
+}
+
+// cloudEventsLabel describes the CloudEvents mode of o for the banner.
+func cloudEventsLabel(o CloudEventsOptions) string {
+	if o.Mode == CloudEventsBatch {
+		label := fmt.Sprintf("batches of up to %d", o.batchSize())
+		if o.BatchWait > 0 {
+			label += fmt.Sprintf(", sent after at most %s", o.BatchWait)
+		}
+		return label
+	}
+	return o.Mode + " mode"
+}
+
+// batchSize returns the number of payloads per request, or 0 when payloads
+// are not batched.
+func (o CloudEventsOptions) batchSize() int {
+	if o.Mode != CloudEventsBatch {
+		return 0
+	}
+	return max(o.BatchSize, 1)
+}
+
+// encodeBatch returns the body of a batched delivery of payloads: a JSON
+// array of structured CloudEvents.
+func (cfg DeliveryConfig) encodeBatch(payloads []WebhookPayload) ([]byte, error) {
+	events := make([]cloudEvent, 0, len(payloads))
+	for _, payload := range payloads {
+		data, err := cfg.encodeData(payload)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, cfg.CloudEvents.event(payload, data))
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return body, nil
+}
+
+// DeliverBatch sends payloads to cfg.URL as one batch of CloudEvents, signed
+// like a single delivery, and retries failed attempts according to
+// cfg.Retry. The signing headers carry the first payload's event ID. Every
+// payload shares the outcome of the request, so onAttempt and the returned
+// slice receive one result per payload, in order; indices holds their
+// indexes.
+func DeliverBatch(ctx context.Context, payloads []WebhookPayload, indices []int, cfg DeliveryConfig, onAttempt func([]DeliveryResult)) []DeliveryResult {
+	perPayload := func(r DeliveryResult) []DeliveryResult {
+		results := make([]DeliveryResult, len(payloads))
+		for i, payload := range payloads {
+			results[i] = r
+			results[i].Index = indices[i]
+			results[i].EventID = payload.EventID
+			results[i].CommonName = payload.Data.CommonName
+		}
+		return results
+	}
+
+	send := func() DeliveryResult {
+		result := DeliveryResult{Target: cfg.Target}
+		body, err := cfg.encodeBatch(payloads)
+		if err != nil {
+			result.Error = err.Error()
+			result.permanent = true
+			return result
+		}
+		return sendBody(ctx, payloads[0], body, cfg, result, requestTamper{})
+	}
+
+	var report func(DeliveryResult)
+	if onAttempt != nil {
+		report = func(r DeliveryResult) { onAttempt(perPayload(r)) }
+	}
+	return perPayload(deliverWithRetry(ctx, cfg, send, report))
+}
@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expectations declares what a successful delivery looks like. The zero
+// value accepts any 2xx response.
+type Expectations struct {
+	Statuses   []int               // Accepted status codes; empty means any 2xx.
+	MaxLatency time.Duration       // Latency budget; zero means no limit.
+	Headers    []HeaderExpectation // Required response headers.
+	Body       []BodyExpectation   // JSON path matches on the response body.
+}
+
+// HeaderExpectation requires a response header, optionally with an exact
+// value.
+type HeaderExpectation struct {
+	Name  string
+	Value string // Empty means the header only has to be present.
+}
+
+// BodyExpectation requires the JSON value at Path in the response body to
+// exist and, if HasValue is set, to equal Value.
+type BodyExpectation struct {
+	Path     string
+	Value    string // A JSON literal, or a bare string.
+	HasValue bool
+}
+
+// ParseStatusList parses a comma-separated list of HTTP status codes, such
+// as "200,202".
+func ParseStatusList(s string) ([]int, error) {
+	var statuses []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		code, err := strconv.Atoi(part)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		statuses = append(statuses, code)
+	}
+	return statuses, nil
+}
+
+// ParseHeaderExpectation parses "Name" or "Name: value".
+func ParseHeaderExpectation(s string) (HeaderExpectation, error) {
+	name, value, _ := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return HeaderExpectation{}, fmt.Errorf("invalid header expectation %q (want Name or Name: value)", s)
+	}
+	return HeaderExpectation{Name: name, Value: strings.TrimSpace(value)}, nil
+}
+
+// ParseBodyExpectation parses "path" or "path=value", where path is a
+// dot-separated JSON path such as "$.data.ids[0]" or "status".
+func ParseBodyExpectation(s string) (BodyExpectation, error) {
+	path, value, hasValue := strings.Cut(s, "=")
+	path = strings.TrimSpace(path)
+	if _, err := splitJSONPath(path); err != nil {
+		return BodyExpectation{}, err
+	}
+	return BodyExpectation{Path: path, Value: strings.TrimSpace(value), HasValue: hasValue}, nil
+}
+
+// statusMet reports whether status is an accepted response status.
+func (e Expectations) statusMet(status int) bool {
+	if len(e.Statuses) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range e.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// statusLabel describes the accepted statuses, e.g. "200 or 202".
+func (e Expectations) statusLabel() string {
+	if len(e.Statuses) == 0 {
+		return "2xx"
+	}
+	codes := make([]string, len(e.Statuses))
+	for i, s := range e.Statuses {
+		codes[i] = strconv.Itoa(s)
+	}
+	return strings.Join(codes, " or ")
+}
+
+// check returns a description of every unmet expectation other than the
+// status code.
+func (e Expectations) check(latency time.Duration, header http.Header, body []byte) []string {
+	var failures []string
+
+	if e.MaxLatency > 0 && latency > e.MaxLatency {
+		failures = append(failures, fmt.Sprintf("latency %dms exceeds %dms", latency.Milliseconds(), e.MaxLatency.Milliseconds()))
+	}
+
+	for _, h := range e.Headers {
+		values, ok := header[http.CanonicalHeaderKey(h.Name)]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("missing header %s", h.Name))
+		case h.Value != "" && !containsString(values, h.Value):
+			failures = append(failures, fmt.Sprintf("header %s is %q, want %q", h.Name, strings.Join(values, ", "), h.Value))
+		}
+	}
+
+	if len(e.Body) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return append(failures, "response body is not JSON")
+		}
+		for _, b := range e.Body {
+			if msg := b.check(doc); msg != "" {
+				failures = append(failures, msg)
+			}
+		}
+	}
+
+	return failures
+}
+
+// check returns a description of why b is not met by doc, or "" if it is.
+func (b BodyExpectation) check(doc any) string {
+	got, ok := lookupJSONPath(doc, b.Path)
+	if !ok {
+		return fmt.Sprintf("body %s is missing", b.Path)
+	}
+	if !b.HasValue {
+		return ""
+	}
+
+	var want any
+	if err := json.Unmarshal([]byte(b.Value), &want); err != nil {
+		want = b.Value // Bare strings need no quotes.
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		return fmt.Sprintf("body %s is %s, want %s", b.Path, gotJSON, b.Value)
+	}
+	return ""
+}
+
+// splitJSONPath splits a path such as "$.items[0].id" into the segments
+// "items", "0", "id".
+func splitJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid JSON path %q", path)
+	}
+	trimmed = strings.NewReplacer("[", ".", "]", "").Replace(trimmed)
+
+	segments := strings.Split(trimmed, ".")
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// lookupJSONPath returns the value at path in doc, a value decoded by
+// encoding/json.
+func lookupJSONPath(doc any, path string) (any, bool) {
+	segments, err := splitJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	cur := doc
+	for _, seg := range segments {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PayloadFilter selects which payloads are written and delivered. A payload
+// passes when it matches every condition; the zero value passes everything.
+type PayloadFilter struct {
+	conditions []filterCondition
+	exprs      []string
+}
+
+// FilterStats counts the payloads a filter let through and held back.
+type FilterStats struct {
+	Expression string `json:"expression"`
+	Matched    int    `json:"matched"`
+	Filtered   int    `json:"filtered"`
+}
+
+// filterCondition is a single parsed -filter expression.
+type filterCondition func(d PayloadData) bool
+
+// ParsePayloadFilter parses -filter expressions. Each expression is one of:
+//
+//	domain=GLOB[,GLOB...]   a domain or the common name matches a glob
+//	domain=/REGEX/          a domain or the common name matches a regexp
+//	issuer=GLOB[,GLOB...]   the issuer organization or CN matches a glob
+//	ct-log=GLOB[,GLOB...]   the certificate was seen in a matching CT log
+//	wildcard                a domain or the common name is a wildcard name
+//	validity<=DURATION      the validity period is at most DURATION
+//	validity>=DURATION      the validity period is at least DURATION
+//	not-before<=DATE        and >=, <, > comparisons on NotBefore
+//	not-after<=DATE         and >=, <, > comparisons on NotAfter
+//
+// Globs are case-insensitive. Durations accept a "d" suffix for days, e.g.
+// "90d". Dates are RFC 3339 timestamps or YYYY-MM-DD.
+func ParsePayloadFilter(exprs []string) (PayloadFilter, error) {
+	f := PayloadFilter{exprs: exprs}
+	for _, expr := range exprs {
+		cond, err := parseFilterCondition(strings.TrimSpace(expr))
+		if err != nil {
+			return PayloadFilter{}, err
+		}
+		f.conditions = append(f.conditions, cond)
+	}
+	return f, nil
+}
+
+// Active reports whether f has any conditions.
+func (f PayloadFilter) Active() bool {
+	return len(f.conditions) > 0
+}
+
+// String returns the filter expressions joined for display.
+func (f PayloadFilter) String() string {
+	return strings.Join(f.exprs, " and ")
+}
+
+// Match reports whether payload passes every condition of f.
+func (f PayloadFilter) Match(payload WebhookPayload) bool {
+	for _, cond := range f.conditions {
+		if !cond(payload.Data) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterOperators lists the comparison operators, longest first so that
+// "<=" is found before "<".
+var filterOperators = []string{"<=", ">=", "=", "<", ">"}
+
+func parseFilterCondition(expr string) (filterCondition, error) {
+	if expr == "wildcard" {
+		return func(d PayloadData) bool {
+			return anyName(d, func(name string) bool { return strings.HasPrefix(name, "*.") })
+		}, nil
+	}
+
+	key, op, value := "", "", ""
+	for i := range expr {
+		for _, candidate := range filterOperators {
+			if strings.HasPrefix(expr[i:], candidate) {
+				key, op, value = expr[:i], candidate, expr[i+len(candidate):]
+				break
+			}
+		}
+		if op != "" {
+			break
+		}
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if op == "" || key == "" || value == "" {
+		return nil, fmt.Errorf("invalid filter %q (want key=value, e.g. domain=*.example.com)", expr)
+	}
+
+	switch key {
+	case "domain", "issuer", "ct-log":
+		if op != "=" {
+			return nil, fmt.Errorf("invalid filter %q: %s only supports =", expr, key)
+		}
+		match, err := parseNameMatcher(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+		}
+		switch key {
+		case "domain":
+			return func(d PayloadData) bool { return anyName(d, match) }, nil
+		case "issuer":
+			return func(d PayloadData) bool { return match(d.IssuerOrg) || match(d.IssuerCN) }, nil
+		default:
+			return func(d PayloadData) bool {
+				for _, log := range d.CTLogSources {
+					if match(log) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		}
+
+	case "validity":
+		limit, err := parseFilterDuration(value)
+		if err != nil || op == "=" {
+			return nil, fmt.Errorf("invalid filter %q (want validity<=DURATION or validity>=DURATION, e.g. validity<=90d)", expr)
+		}
+		return func(d PayloadData) bool {
+			notBefore, err1 := time.Parse(time.RFC3339, d.NotBefore)
+			notAfter, err2 := time.Parse(time.RFC3339, d.NotAfter)
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			return compareFilterValues(notAfter.Sub(notBefore), limit, op)
+		}, nil
+
+	case "not-before", "not-after":
+		limit, err := parseFilterDate(value)
+		if err != nil || op == "=" {
+			return nil, fmt.Errorf("invalid filter %q (want e.g. %s>=2026-01-01)", expr, key)
+		}
+		return func(d PayloadData) bool {
+			field := d.NotBefore
+			if key == "not-after" {
+				field = d.NotAfter
+			}
+			t, err := time.Parse(time.RFC3339, field)
+			if err != nil {
+				return false
+			}
+			return compareFilterValues(t.Sub(limit), 0, op)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown filter key %q (want domain, issuer, ct-log, wildcard, validity, not-before, or not-after)", key)
+}
+
+// parseNameMatcher parses "/regex/" or a comma-separated list of globs into
+// a matcher.
+func parseNameMatcher(value string) (func(string) bool, error) {
+	if len(value) > 1 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		re, err := regexp.Compile(value[1 : len(value)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	globs := strings.Split(strings.ToLower(value), ",")
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q", glob)
+		}
+	}
+	return func(s string) bool {
+		s = strings.ToLower(s)
+		for _, glob := range globs {
+			if ok, _ := path.Match(glob, s); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// anyName reports whether match holds for the common name or any domain.
+func anyName(d PayloadData, match func(string) bool) bool {
+	if d.CommonName != "" && match(d.CommonName) {
+		return true
+	}
+	for _, domain := range d.Domains {
+		if match(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFilterDuration parses a Go duration or a whole number of days, such
+// as "90d".
+func parseFilterDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseFilterDate parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC).
+func parseFilterDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// compareFilterValues applies a comparison operator to a and b.
+func compareFilterValues(a, b time.Duration, op string) bool {
+	switch op {
+	case "<=":
+		return a <= b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case ">":
+		return a > b
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPayloadFilterMatch(t *testing.T) {
+	payload := WebhookPayload{Data: PayloadData{
+		CommonName:   "*.Example.com",
+		Domains:      []string{"*.example.com", "example.com", "api.example.org"},
+		IssuerOrg:    "Let's Encrypt",
+		IssuerCN:     "R11",
+		NotBefore:    "2026-01-01T00:00:00Z",
+		NotAfter:     "2026-04-01T00:00:00Z", // 90 days later.
+		CTLogSources: []string{"Google 'Argon2026h1'", "Cloudflare 'Nimbus2026'"},
+	}}
+
+	tests := []struct {
+		exprs []string
+		want  bool
+	}{
+		{nil, true},
+
+		// Globs are case-insensitive and match the common name or any domain.
+		{[]string{"domain=example.com"}, true},
+		{[]string{"domain=*.EXAMPLE.COM"}, true},
+		{[]string{"domain=api.example.*"}, true},
+		{[]string{"domain=example.net"}, false},
+		{[]string{"  domain = example.com  "}, true},
+
+		// Commas separate alternative globs.
+		{[]string{"domain=example.net,api.example.org"}, true},
+		{[]string{"domain=example.net,example.io"}, false},
+
+		// Slashes make a regexp, in which commas and operators are literal
+		// and case matters.
+		{[]string{`domain=/^api\.example\.(org|net)$/`}, true},
+		{[]string{"domain=/^[a-z]{2,3}\\.example\\.org$/"}, true},
+		{[]string{"domain=/^API/"}, false},
+		{[]string{"domain=/a=b|<=|example/"}, true},
+		{[]string{"domain=/"}, false},
+
+		{[]string{"issuer=let's*"}, true},
+		{[]string{"issuer=r1?"}, true},
+		{[]string{"issuer=DigiCert*"}, false},
+		{[]string{"ct-log=*nimbus*"}, true},
+		{[]string{"ct-log=*xenon*"}, false},
+
+		{[]string{"wildcard"}, true},
+
+		{[]string{"validity<=90d"}, true},
+		{[]string{"validity<90d"}, false},
+		{[]string{"validity>=2160h"}, true},
+		{[]string{"validity>90d"}, false},
+		{[]string{"validity<=89d"}, false},
+
+		{[]string{"not-before>=2026-01-01"}, true},
+		{[]string{"not-before>2026-01-01"}, false},
+		{[]string{"not-after<2026-04-01T00:00:01Z"}, true},
+		{[]string{"not-after<=2026-03-31"}, false},
+
+		// Every expression must match.
+		{[]string{"domain=example.com", "wildcard", "issuer=let's*"}, true},
+		{[]string{"domain=example.com", "validity>90d"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.exprs, " and "), func(t *testing.T) {
+			f, err := ParsePayloadFilter(tt.exprs)
+			if err != nil {
+				t.Fatalf("ParsePayloadFilter(%q) error = %v", tt.exprs, err)
+			}
+			if got := f.Match(payload); got != tt.want {
+				t.Errorf("ParsePayloadFilter(%q).Match() = %v, want %v", tt.exprs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadFilterMatchUnparseableDates(t *testing.T) {
+	payload := WebhookPayload{Data: PayloadData{NotBefore: "yesterday", NotAfter: ""}}
+	for _, expr := range []string{"validity<=90d", "validity>=1d", "not-before<=2030-01-01", "not-after>=2000-01-01"} {
+		f, err := ParsePayloadFilter([]string{expr})
+		if err != nil {
+			t.Fatalf("ParsePayloadFilter(%q) error = %v", expr, err)
+		}
+		if f.Match(payload) {
+			t.Errorf("%s matched a payload without valid dates", expr)
+		}
+	}
+}
+
+func TestParsePayloadFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"", "want key=value"},
+		{"domain", "want key=value"},
+		{"domain=", "want key=value"},
+		{"=example.com", "want key=value"},
+		{"color=blue", "unknown filter key"},
+		{"domain<=example.com", "only supports ="},
+		{"issuer>x", "only supports ="},
+		{"domain=[", "invalid glob"},
+		{"domain=ok,[", "invalid glob"},
+		{"domain=/(/", "missing closing )"},
+		{"validity=90d", "want validity<=DURATION"},
+		{"validity<=ninety", "want validity<=DURATION"},
+		{"validity<=-5d", "want validity<=DURATION"},
+		{"not-before=2026-01-01", "not-before>=2026-01-01"},
+		{"not-after<=01/02/2026", "not-after>=2026-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParsePayloadFilter([]string{tt.expr})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePayloadFilter(%q) error = %v, want it to contain %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
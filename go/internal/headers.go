@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParseRequestHeader parses a "Name: value" request header, as passed to
+// -header.
+func ParseRequestHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q (want 'Name: value')", s)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// ExpandEnv replaces $VAR and ${VAR} in s with the values of environment
+// variables. "$$" yields a literal "$". Unlike os.ExpandEnv, referencing an
+// unset variable is an error, so a missing token is not silently sent as an
+// empty header.
+func ExpandEnv(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// requestHeaders returns the extra headers sent with every delivery: o.Headers
+// sorted by name, followed by an Authorization header for o.BasicAuth or
+// o.BearerToken.
+func (o DeliveryOptions) requestHeaders() []headerField {
+	names := make([]string, 0, len(o.Headers))
+	for name := range o.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers []headerField
+	for _, name := range names {
+		for _, value := range o.Headers[name] {
+			headers = append(headers, headerField{name, value})
+		}
+	}
+
+	switch {
+	case o.BasicAuth != "":
+		headers = append(headers, headerField{"Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(o.BasicAuth))})
+	case o.BearerToken != "":
+		headers = append(headers, headerField{"Authorization", "Bearer " + o.BearerToken})
+	}
+	return headers
+}
+
+// isSigningHeader reports whether name is one of the webhook headers that
+// identify and sign a delivery.
+func isSigningHeader(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "X-Certwatch-Event-Id", "X-Certwatch-Timestamp", "X-Certwatch-Signature",
+		"Webhook-Id", "Webhook-Timestamp", "Webhook-Signature":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the deliveries of a single run.
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	Cases      []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a name/value pair attached to a test suite.
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase is a single delivery.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Error     *JUnitFailure `xml:"error,omitempty"`
+}
+
+// JUnitFailure describes why a test case failed. Non-2xx responses are
+// reported as failures and network errors as errors.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// BuildJUnit converts the final delivery results of a run into a JUnit test
+// suite with one test case per delivery, named by event ID and common name.
+// Unexpected statuses and unmet expectations are failures; network errors are
+// errors.
+func BuildJUnit(suiteName, target string, startedAt time.Time, elapsedMs int64, results []DeliveryResult) JUnitTestSuites {
+	suite := JUnitTestSuite{
+		Name:      suiteName,
+		Tests:     len(results),
+		Time:      formatJUnitSeconds(elapsedMs),
+		Timestamp: startedAt.UTC().Format("2006-01-02T15:04:05"),
+		Properties: append([]JUnitProperty{{Name: "target", Value: target}},
+			latencyProperties(computeLatencyStats(results))...),
+		Cases: make([]JUnitTestCase, 0, len(results)),
+	}
+
+	for _, r := range results {
+		tc := JUnitTestCase{
+			Name:      fmt.Sprintf("#%d %s (%s)", r.Index, r.EventID, r.CommonName),
+			Classname: suiteName,
+			Time:      formatJUnitSeconds(r.LatencyMs),
+		}
+		if r.Target != "" {
+			tc.Name += " [" + r.Target + "]"
+			tc.Classname += "." + r.Target
+		}
+
+		if !r.Success {
+			text := fmt.Sprintf("target: %s\nevent_id: %s\ncommon_name: %s\nattempts: %d\n",
+				cmp.Or(r.Target, target), r.EventID, r.CommonName, max(r.Attempt, 1))
+
+			if r.Status == 0 {
+				tc.Error = &JUnitFailure{Message: r.Error, Type: "DeliveryError", Text: text}
+				suite.Errors++
+			} else if len(r.ExpectationFailures) > 0 {
+				text += fmt.Sprintf("status: %d %s\n", r.Status, r.StatusText)
+				for _, f := range r.ExpectationFailures {
+					text += "expectation: " + f + "\n"
+				}
+				text += responseText(r)
+				tc.Failure = &JUnitFailure{Message: r.Error, Type: "ExpectationFailed", Text: text}
+				suite.Failures++
+			} else {
+				message := fmt.Sprintf("%d %s", r.Status, r.StatusText)
+				text += fmt.Sprintf("status: %s\n", message)
+				text += responseText(r)
+				if r.Error != "" {
+					message = r.Error
+				}
+				tc.Failure = &JUnitFailure{Message: message, Type: "UnexpectedStatus", Text: text}
+				suite.Failures++
+			}
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	return JUnitTestSuites{
+		Name:     suiteName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []JUnitTestSuite{suite},
+	}
+}
+
+// WriteJUnit writes suites to path as JUnit XML.
+func WriteJUnit(path string, suites JUnitTestSuites) error {
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report %s: %w", path, err)
+	}
+	return nil
+}
+
+// responseText returns the captured response body for a failure message, or
+// "" if the endpoint sent none.
+func responseText(r DeliveryResult) string {
+	if r.ResponseBody == "" {
+		return ""
+	}
+	text := "response:\n" + r.ResponseBody + "\n"
+	if r.ResponseBodyTruncated {
+		text += fmt.Sprintf("(truncated at %d bytes)\n", maxResponseBodySize)
+	}
+	return text
+}
+
+// latencyProperties returns the latency statistics as suite properties so CI
+// dashboards can chart them alongside pass/fail counts.
+func latencyProperties(stats LatencyStats) []JUnitProperty {
+	ms := func(v int64) string { return fmt.Sprintf("%d", v) }
+	return []JUnitProperty{
+		{Name: "latency_avg_ms", Value: ms(stats.AvgMs)},
+		{Name: "latency_p50_ms", Value: ms(stats.P50Ms)},
+		{Name: "latency_p90_ms", Value: ms(stats.P90Ms)},
+		{Name: "latency_p95_ms", Value: ms(stats.P95Ms)},
+		{Name: "latency_p99_ms", Value: ms(stats.P99Ms)},
+		{Name: "latency_max_ms", Value: ms(stats.MaxMs)},
+	}
+}
+
+// formatJUnitSeconds formats milliseconds as the seconds value JUnit expects.
+func formatJUnitSeconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000.0)
+}
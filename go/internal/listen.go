@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxListenBodySize caps the request body accepted by the listener.
+const maxListenBodySize = 1024 * 1024
+
+// receivedPayload describes a single request handled by the listener.
+type receivedPayload struct {
+	Index    int
+	Payload  WebhookPayload
+	Verified bool
+	Error    string // Why verification or decoding failed, if it did.
+}
+
+// listener is the state shared by the listen command's HTTP handlers.
+type listener struct {
+	opts    ListenOptions
+	outFile *os.File
+
+	mu       sync.Mutex
+	received int
+	verified int
+	failed   int
+	done     chan struct{} // Closed once opts.Count payloads have been received.
+	doneOnce sync.Once
+}
+
+// Listen runs a webhook receiver that verifies the signature headers
+// selected by opts.Profile on every request, decodes the body into a
+// WebhookPayload, and optionally appends it to a JSONL file. It runs until
+// interrupted or until opts.Count payloads have been received, and returns
+// an error if any request failed verification.
+func Listen(opts ListenOptions, version string) error {
+	SetColor(!opts.NoColor)
+
+	l := &listener{opts: opts, done: make(chan struct{})}
+
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file %s: %w", opts.File, err)
+		}
+		defer f.Close() //nolint:errcheck // file close on exit is non-actionable
+		l.outFile = f
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", l.handleHealth)
+	mux.HandleFunc(opts.Path, l.handleWebhook)
+
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	displayHost := opts.Host
+	if displayHost == "" {
+		displayHost = "localhost"
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	PrintListenBanner(version, "http://"+net.JoinHostPort(displayHost, strconv.Itoa(port))+opts.Path, opts.File)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-l.done:
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("listener error: %w", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+
+	l.mu.Lock()
+	received, verified, failed := l.received, l.verified, l.failed
+	l.mu.Unlock()
+
+	PrintListenSummary(received, verified, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d payloads failed verification", failed, received)
+	}
+	return nil
+}
+
+// handleHealth reports that the listener is up and how many payloads it has
+// received.
+func (l *listener) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	l.mu.Lock()
+	received := l.received
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"status":"ok","received":%d}`, received)
+}
+
+// handleWebhook verifies and records a single webhook delivery.
+func (l *listener) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "POST only")
+		return
+	}
+
+	rec := receivedPayload{}
+	status := http.StatusOK
+
+	// Decode even when the signature fails so the output can show which
+	// event was affected.
+	var decodeErr, verifyErr error
+	body, readErr := io.ReadAll(http.MaxBytesReader(w, r.Body, maxListenBodySize))
+	if readErr == nil {
+		decodeErr = json.Unmarshal(body, &rec.Payload)
+		verifyErr = l.verify(body, r.Header)
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(readErr, &tooLarge):
+		rec.Error = fmt.Sprintf("body exceeds %d bytes", maxListenBodySize)
+		status = http.StatusRequestEntityTooLarge
+	case readErr != nil:
+		rec.Error = fmt.Sprintf("failed to read body: %v", readErr)
+		status = http.StatusBadRequest
+	case verifyErr != nil:
+		rec.Error = verifyErr.Error()
+		status = http.StatusUnauthorized
+	case decodeErr != nil:
+		rec.Error = fmt.Sprintf("invalid payload: %v", decodeErr)
+		status = http.StatusBadRequest
+	default:
+		rec.Verified = true
+	}
+
+	l.mu.Lock()
+	l.received++
+	rec.Index = l.received
+	if rec.Verified {
+		l.verified++
+		if l.outFile != nil {
+			var line bytes.Buffer
+			if err := json.Compact(&line, body); err == nil {
+				_, _ = fmt.Fprintln(l.outFile, line.String())
+			}
+		}
+	} else {
+		l.failed++
+	}
+	PrintReceived(rec)
+	if l.opts.Verbose {
+		PrintVerbosePayload(rec.Payload)
+	}
+	reachedCount := l.opts.Count > 0 && l.received >= l.opts.Count
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `{"received":true,"verified":%t}`, rec.Verified)
+
+	if reachedCount {
+		l.doneOnce.Do(func() { close(l.done) })
+	}
+}
+
+// verify checks the request's signature headers against body. The request
+// is accepted if it verifies with either opts.Secret or opts.NextSecret.
+func (l *listener) verify(body []byte, header http.Header) error {
+	err := l.verifyWith(body, header, l.opts.Secret)
+	if err != nil && l.opts.NextSecret != "" {
+		if nextErr := l.verifyWith(body, header, l.opts.NextSecret); nextErr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// verifyWith checks the request's signature headers against body using
+// secret and the configured header profile and signature scheme.
+func (l *listener) verifyWith(body []byte, header http.Header, secret string) error {
+	now := time.Now()
+
+	if l.opts.Profile != HeaderProfileStandardWebhooks {
+		signature := header.Get("X-CertWatch-Signature")
+		if l.opts.SignatureScheme == SignatureSchemeTimestamped {
+			if err := VerifyTimestampedSignature(body, signature, secret, l.opts.Tolerance, now); err != nil {
+				return err
+			}
+		} else if !VerifySignature(body, signature, secret) {
+			return errSignatureMismatch
+		}
+	}
+
+	if l.opts.Profile == HeaderProfileStandardWebhooks || l.opts.Profile == HeaderProfileBoth {
+		err := VerifyStandardWebhooks(body, header.Get("webhook-id"), header.Get("webhook-timestamp"),
+			header.Get("webhook-signature"), secret, l.opts.Tolerance, now)
+		if err != nil {
+			return fmt.Errorf("webhook-signature: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeJSONError writes a {"error": message} response with the given status.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
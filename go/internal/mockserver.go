@@ -0,0 +1,317 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const streamPath = "/api/v1/tools/webhook-test/stream"
+
+// mockServer implements the subset of the CertWatch API used by the CLI:
+// session creation and the SSE payload stream.
+type mockServer struct {
+	opts     MockServerOptions
+	fixture  []WebhookPayload // Nil when payloads are generated.
+	mu       sync.Mutex
+	sessions map[string]string    // Session secret -> test ID.
+	started  map[string]time.Time // Session secret -> start of its current stream.
+}
+
+// MockServer runs a local stand-in for the CertWatch API so the CLI can be
+// exercised end-to-end without network access. Point the CLI at it with
+// -api-endpoint http://localhost:PORT. Payloads come from opts.Fixture if
+// set, or from GenerateSamplePayload otherwise.
+func MockServer(opts MockServerOptions, version string) error {
+	SetColor(!opts.NoColor)
+
+	m := &mockServer{
+		opts:     opts,
+		sessions: make(map[string]string),
+		started:  make(map[string]time.Time),
+	}
+
+	if opts.Fixture != "" {
+		payloads, err := LoadPayloads(opts.Fixture)
+		if err != nil {
+			return err
+		}
+		if len(payloads) == 0 {
+			return fmt.Errorf("no payloads found in %s", opts.Fixture)
+		}
+		m.fixture = payloads
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(sessionPath, m.handleSession)
+	mux.HandleFunc(streamPath, m.handleStream)
+
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Streams are long-lived; end them when the server shuts down.
+	server.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	displayHost := opts.Host
+	if displayHost == "" {
+		displayHost = "localhost"
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	source := "generated samples"
+	if opts.Fixture != "" {
+		source = fmt.Sprintf("%s (%d payloads)", opts.Fixture, len(m.fixture))
+	}
+	PrintMockServerBanner(version, "http://"+net.JoinHostPort(displayHost, strconv.Itoa(port)), source, opts.Rate, opts.Duration)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("mock server error: %w", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+
+	return nil
+}
+
+// handleSession implements POST /api/v1/tools/webhook-test/session.
+func (m *mockServer) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeSessionError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "POST only")
+		return
+	}
+	if r.Header.Get("X-API-Key") == "" {
+		writeSessionError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing X-API-Key header")
+		return
+	}
+
+	var req struct {
+		Secret string `json:"secret"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSessionError(w, http.StatusBadRequest, "INVALID_BODY", "request body must be JSON")
+			return
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = m.opts.Secret
+	}
+	if secret == "" {
+		secret = randomHex(32)
+	}
+	testID := "test_" + generateUUIDv4()
+
+	m.mu.Lock()
+	m.sessions[secret] = testID
+	m.mu.Unlock()
+
+	durationSec := int(m.opts.Duration / time.Second)
+	resp := SessionResponse{
+		Success: true,
+		Data: &SessionData{
+			TestID:                testID,
+			Secret:                secret,
+			StreamURL:             "http://" + r.Host + streamPath,
+			ExpiresInSeconds:      durationSec + 60,
+			StreamDurationSeconds: durationSec,
+		},
+	}
+
+	PrintInfo("Session created: " + testID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleStream implements GET /api/v1/tools/webhook-test/stream. It sends a
+// meta event, then payload events at the configured rate until the stream
+// duration elapses or the fixture is exhausted, then a complete event.
+// Payload events carry sequential IDs, and a Last-Event-ID header resumes
+// the stream after that event.
+func (m *mockServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	secret := r.URL.Query().Get("secret")
+	if secret == "" {
+		secret = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	testID, ok := m.authorize(secret)
+	if !ok {
+		writeSessionError(w, http.StatusUnauthorized, "INVALID_SECRET", "unknown or missing secret")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeSessionError(w, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event, id string, data interface{}) {
+		body, err := json.Marshal(data)
+		if err != nil {
+			return
+		}
+		if id != "" {
+			_, _ = fmt.Fprintf(w, "id: %s\n", id)
+		}
+		if event != "" {
+			_, _ = fmt.Fprintf(w, "event: %s\n", event)
+		}
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", body)
+		flusher.Flush()
+	}
+
+	// Resume after the last event the client saw, if it sent one.
+	seq := 0
+	resumed := false
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && lastID > 0 {
+		seq, resumed = lastID, true
+		PrintInfo(fmt.Sprintf("Stream resumed: %s (after event %d)", testID, seq))
+	} else {
+		PrintInfo("Stream opened: " + testID)
+	}
+
+	if m.opts.Retry > 0 {
+		_, _ = fmt.Fprintf(w, "retry: %d\n\n", m.opts.Retry.Milliseconds())
+	}
+	send("meta", "", StreamMeta{
+		TestID:                testID,
+		StreamDurationSeconds: int(m.opts.Duration / time.Second),
+	})
+
+	// The stream duration is measured from the connection that started the
+	// stream, so resuming does not extend it.
+	ctx := r.Context()
+	deadline := time.NewTimer(time.Until(m.streamStart(secret, resumed).Add(m.opts.Duration)))
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / m.opts.Rate))
+	defer ticker.Stop()
+
+	sent := 0
+	for {
+		select {
+		case <-ctx.Done():
+			PrintInfo(fmt.Sprintf("Stream closed by client: %s (%d payloads)", testID, seq))
+			return
+
+		case <-deadline.C:
+			send("complete", "", map[string]string{"message": "Stream duration reached"})
+			PrintInfo(fmt.Sprintf("Stream complete: %s (%d payloads)", testID, seq))
+			return
+
+		case <-ticker.C:
+			if m.opts.ErrorAfter > 0 && seq >= m.opts.ErrorAfter {
+				send("error", "", map[string]string{"message": "Simulated stream error"})
+				PrintInfo(fmt.Sprintf("Stream error sent: %s (%d payloads)", testID, seq))
+				return
+			}
+
+			if m.fixture != nil && seq >= len(m.fixture) {
+				send("complete", "", map[string]string{"message": "Fixture exhausted"})
+				PrintInfo(fmt.Sprintf("Stream complete: %s (%d payloads)", testID, seq))
+				return
+			}
+
+			if m.opts.DropAfter > 0 && sent >= m.opts.DropAfter {
+				PrintInfo(fmt.Sprintf("Stream dropped: %s (after event %d)", testID, seq))
+				// Abort the connection without terminating the response.
+				panic(http.ErrAbortHandler)
+			}
+
+			var payload WebhookPayload
+			if m.fixture != nil {
+				payload = m.fixture[seq]
+			} else {
+				payload = GenerateSamplePayload()
+			}
+			seq++
+			sent++
+			send("", strconv.Itoa(seq), payload)
+		}
+	}
+}
+
+// streamStart returns the time the current stream for secret started. A
+// fresh connection starts a new stream, so later runs with the same secret
+// get the full duration; a resumed one keeps the earlier start time.
+func (m *mockServer) streamStart(secret string, resumed bool) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start, ok := m.started[secret]
+	if !ok || !resumed {
+		start = time.Now()
+		m.started[secret] = start
+	}
+	return start
+}
+
+// authorize checks a stream secret and returns the test ID to report for it.
+// Secrets issued by the session endpoint are always accepted. Otherwise the
+// secret must match opts.Secret, or be non-empty if no secret is configured.
+func (m *mockServer) authorize(secret string) (string, bool) {
+	if secret == "" {
+		return "", false
+	}
+
+	m.mu.Lock()
+	testID, ok := m.sessions[secret]
+	m.mu.Unlock()
+	if ok {
+		return testID, true
+	}
+
+	if m.opts.Secret != "" && secret != m.opts.Secret {
+		return "", false
+	}
+
+	// Remember the secret so a reconnecting client keeps its test ID.
+	testID = "test_" + generateUUIDv4()
+	m.mu.Lock()
+	m.sessions[secret] = testID
+	m.mu.Unlock()
+	return testID, true
+}
+
+// writeSessionError writes an error in the CertWatch API envelope format.
+func writeSessionError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(SessionResponse{
+		Error: &SessionError{Code: code, Message: message},
+	})
+}
@@ -1,10 +1,13 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
 // ANSI color codes for terminal output.
@@ -63,41 +66,165 @@ func PrintConnected() {
 }
 
 // PrintDelivery prints a single delivery result line showing the index,
-// common name, HTTP status, and latency.
+// common name, HTTP status, and latency. Retry attempts are annotated with
+// the attempt number and the backoff before the next attempt.
 func PrintDelivery(result DeliveryResult) {
 	index := fmt.Sprintf("#%-3d", result.Index)
 	cn := truncate(result.CommonName, 28)
-	cn = fmt.Sprintf("%-28s", cn)
+	cn = targetPrefix(result) + fmt.Sprintf("%-28s", cn)
+	retry := formatRetry(result)
 
 	if result.Success {
 		status := fmt.Sprintf("%d %s", result.Status, result.StatusText)
 		latency := fmt.Sprintf("(%dms)", result.LatencyMs)
-		fmt.Printf("  %s %s %s %s  %s\n",
+		fmt.Printf("  %s %s %s %s  %s%s\n",
 			color(colorDim, index),
 			cn,
 			color(colorDim, "->"),
 			color(colorGreen, status),
 			color(colorDim, latency),
+			retry,
 		)
 	} else if result.Error != "" && result.Status == 0 {
 		// Network error -- no status code.
-		fmt.Printf("  %s %s %s %s\n",
+		fmt.Printf("  %s %s %s %s%s\n",
 			color(colorDim, index),
 			cn,
 			color(colorDim, "->"),
 			color(colorRed, "ERR "+result.Error),
+			retry,
 		)
 	} else {
 		status := fmt.Sprintf("%d %s", result.Status, result.StatusText)
 		latency := fmt.Sprintf("(%dms)", result.LatencyMs)
-		fmt.Printf("  %s %s %s %s  %s\n",
+		fmt.Printf("  %s %s %s %s  %s%s\n",
 			color(colorDim, index),
 			cn,
 			color(colorDim, "->"),
 			color(colorRed, status),
 			color(colorDim, latency),
+			retry,
 		)
+		for _, f := range result.ExpectationFailures {
+			fmt.Printf("       %s %s\n", color(colorRed, "expectation failed:"), f)
+		}
+		if body := responseSnippet(result.ResponseBody); body != "" {
+			fmt.Printf("       %s %s\n", color(colorDim, "response:"), body)
+		}
+	}
+}
+
+// targetPrefix returns the "[target] " label that precedes the common name
+// when deliveries fan out to several targets, or "" otherwise.
+func targetPrefix(result DeliveryResult) string {
+	if result.Target == "" {
+		return ""
+	}
+	return color(colorCyan, "["+result.Target+"]") + " "
+}
+
+// maxResponseSnippet is the number of characters of a response body shown
+// under a failed delivery.
+const maxResponseSnippet = 200
+
+// responseSnippet collapses a response body onto one line and truncates it
+// for display under a failed delivery.
+func responseSnippet(body string) string {
+	return truncate(strings.Join(strings.Fields(body), " "), maxResponseSnippet)
+}
+
+// PrintVerboseResponse prints the captured response headers and body of a
+// delivery when verbose mode is enabled.
+func PrintVerboseResponse(result DeliveryResult) {
+	if result.Status == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(result.ResponseHeaders))
+	for name := range result.ResponseHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("    %s\n", color(colorDim, fmt.Sprintf("Response: %d %s", result.Status, result.StatusText)))
+	if result.TLSVersion != "" {
+		fmt.Printf("    %s\n", color(colorDim, fmt.Sprintf("TLS: %s, %s", result.TLSVersion, result.TLSCipher)))
+	}
+	fmt.Printf("    %s\n", color(colorDim, "Timing: "+formatTiming(result.Timing)))
+	for _, name := range names {
+		for _, value := range result.ResponseHeaders[name] {
+			fmt.Printf("    %s\n", color(colorDim, name+": "+value))
+		}
+	}
+	if result.ResponseBody != "" {
+		body := result.ResponseBody
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, []byte(body), "    ", "  ") == nil {
+			body = pretty.String()
+		}
+		fmt.Printf("    %s\n", color(colorDim, body))
+		if result.ResponseBodyTruncated {
+			fmt.Printf("    %s\n", color(colorDim, fmt.Sprintf("(truncated at %d bytes)", maxResponseBodySize)))
+		}
+	}
+}
+
+// formatTiming describes a timing breakdown, e.g.
+// "dns 1.2ms, connect 0.4ms, tls 8.1ms, ttfb 14.9ms".
+func formatTiming(t DeliveryTiming) string {
+	ms := func(d time.Duration) string { return fmt.Sprintf("%.1fms", durationMs(d)) }
+	if t.Reused {
+		return "ttfb " + ms(t.TTFB) + " (reused connection)"
+	}
+	parts := []string{"dns " + ms(t.DNS), "connect " + ms(t.Connect)}
+	if t.TLS > 0 {
+		parts = append(parts, "tls "+ms(t.TLS))
+	}
+	parts = append(parts, "ttfb "+ms(t.TTFB))
+	return strings.Join(parts, ", ")
+}
+
+// PrintChaosDelivery prints the result of a delivery with an injected chaos
+// fault. The outcome is green when the endpoint handled the fault correctly
+// (usually by rejecting it) and red otherwise.
+func PrintChaosDelivery(result DeliveryResult) {
+	index := fmt.Sprintf("#%-3d", result.Index)
+	cn := truncate(result.CommonName, 28)
+	cn = targetPrefix(result) + fmt.Sprintf("%-28s", cn)
+
+	outcome := fmt.Sprintf("%d %s", result.Status, result.StatusText)
+	if result.Status == 0 {
+		outcome = "ERR " + result.Error
+	}
+	outcomeColor := colorRed
+	if chaosHandledCorrectly(result) {
+		outcomeColor = colorGreen
+	}
+
+	fmt.Printf("  %s %s %s %s %s  %s\n",
+		color(colorDim, index),
+		cn,
+		color(colorDim, "~>"),
+		color(colorYellow, "["+result.Fault+"]"),
+		color(outcomeColor, outcome),
+		color(colorDim, fmt.Sprintf("(%dms)", result.LatencyMs)),
+	)
+}
+
+// formatRetry returns the attempt/backoff annotation for a delivery line, or
+// an empty string for a first attempt that will not be retried.
+func formatRetry(result DeliveryResult) string {
+	var parts []string
+	if result.Attempt > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d", result.Attempt))
+	}
+	if result.RetryIn > 0 {
+		parts = append(parts, fmt.Sprintf("retrying in %s", result.RetryIn.Round(10*time.Millisecond)))
+	}
+	if len(parts) == 0 {
+		return ""
 	}
+	return "  " + color(colorYellow, "["+strings.Join(parts, ", ")+"]")
 }
 
 // PrintFileSaved prints a per-payload progress line for file-only mode.
@@ -113,6 +240,45 @@ func PrintFileSaved(index int, commonName string) {
 	)
 }
 
+// PrintReconnect prints a notice that the stream dropped and the CLI is about
+// to reconnect.
+func PrintReconnect(attempt int, delay time.Duration, cause error) {
+	reason := "stream closed by server"
+	if cause != nil {
+		reason = cause.Error()
+	}
+	fmt.Printf("  %s %s\n",
+		color(colorYellow, "Reconnecting:"),
+		fmt.Sprintf("%s (attempt %d in %s)", reason, attempt, delay.Round(10*time.Millisecond)),
+	)
+}
+
+// PrintStreamStats prints reconnection, gap, and duplicate counts if the
+// stream was interrupted at any point. It prints nothing for a clean stream.
+func PrintStreamStats(stats StreamStats) {
+	if stats.Expired {
+		PrintInfo("Stream dropped after the session expired; not reconnecting")
+	}
+	if stats.Reconnects == 0 && stats.Gaps == 0 && stats.Duplicates == 0 {
+		return
+	}
+
+	line := fmt.Sprintf("%d reconnects, %d missing events, %d duplicates skipped",
+		stats.Reconnects, stats.Gaps, stats.Duplicates)
+	if stats.Gaps > 0 {
+		fmt.Printf("  %s %s\n\n", color(colorYellow, "Stream:"), color(colorYellow, line))
+		return
+	}
+	fmt.Printf("  %s %s\n\n", color(colorCyan, "Stream:"), line)
+}
+
+// PrintFilterStats prints how many payloads matched the -filter expressions.
+func PrintFilterStats(stats FilterStats) {
+	fmt.Printf("  %s %d matched, %d filtered out %s\n\n",
+		color(colorCyan, "Filter:"), stats.Matched, stats.Filtered,
+		color(colorDim, "("+stats.Expression+")"))
+}
+
 // PrintVerbosePayload pretty-prints a JSON payload when verbose mode is enabled.
 func PrintVerbosePayload(payload interface{}) {
 	data, err := json.MarshalIndent(payload, "    ", "  ")
@@ -123,27 +289,30 @@ func PrintVerbosePayload(payload interface{}) {
 }
 
 // PrintSummary prints the final delivery summary showing success rate,
-// failures, elapsed time, and average latency.
+// failures, elapsed time, latency percentiles, and a latency histogram. When
+// retries were made, it also breaks successes down into first-try and
+// eventual (after retry) successes.
 func PrintSummary(results []DeliveryResult, elapsedMs int64) {
 	total := len(results)
 	succeeded := 0
-	var totalLatency int64
+	firstTry := 0
+	retried := false
 
 	for _, r := range results {
 		if r.Success {
 			succeeded++
+			if r.Attempt <= 1 {
+				firstTry++
+			}
+		}
+		if r.Attempt > 1 {
+			retried = true
 		}
-		totalLatency += r.LatencyMs
 	}
 
 	failed := total - succeeded
 	elapsedSec := float64(elapsedMs) / 1000.0
 
-	var avgMs int64
-	if total > 0 {
-		avgMs = totalLatency / int64(total)
-	}
-
 	var pct float64
 	if total > 0 {
 		pct = float64(succeeded) / float64(total) * 100.0
@@ -166,6 +335,17 @@ func PrintSummary(results []DeliveryResult, elapsedMs int64) {
 		color(deliveredColor, fmt.Sprintf("%d/%d (%.1f%%)", succeeded, total, pct)),
 	)
 
+	if retried {
+		fmt.Printf("  %s %s\n",
+			color(colorDim, "First try:"),
+			fmt.Sprintf("%d", firstTry),
+		)
+		fmt.Printf("  %s %s\n",
+			color(colorDim, "Retried:  "),
+			fmt.Sprintf("%d succeeded after retry", succeeded-firstTry),
+		)
+	}
+
 	if failed > 0 {
 		fmt.Printf("  %s %s\n",
 			color(colorDim, "Failed:   "),
@@ -178,13 +358,224 @@ func PrintSummary(results []DeliveryResult, elapsedMs int64) {
 		fmt.Sprintf("%.1fs", elapsedSec),
 	)
 
-	if total > 0 {
+	if fresh, reused := countConnections(results); fresh+reused > 0 {
 		fmt.Printf("  %s %s\n",
-			color(colorDim, "Avg:      "),
-			fmt.Sprintf("%dms", avgMs),
+			color(colorDim, "Conns:    "),
+			fmt.Sprintf("%d new, %d reused", fresh, reused),
+		)
+	}
+
+	if total > 0 {
+		printLatencyTable(results)
+		printHistogram(computeHistogram(results))
+	}
+
+	fmt.Println()
+}
+
+// countConnections counts the deliveries that got a response over a new
+// connection and over a reused keep-alive connection, respectively.
+func countConnections(results []DeliveryResult) (fresh, reused int) {
+	for _, r := range results {
+		switch {
+		case r.Status == 0:
+		case r.Timing.Reused:
+			reused++
+		default:
+			fresh++
+		}
+	}
+	return fresh, reused
+}
+
+// printLatencyTable prints latency percentiles for all deliveries, and
+// separately for successful and failed ones when both are present.
+func printLatencyTable(results []DeliveryResult) {
+	fmt.Println()
+	fmt.Printf("  %s\n", color(colorDim, fmt.Sprintf("%-10s %7s %7s %7s %7s %7s %7s",
+		"Latency", "avg", "p50", "p90", "p95", "p99", "max")))
+	printLatencyRow("all", computeLatencyStats(results))
+
+	succeeded, failed := splitBySuccess(results)
+	if len(succeeded) > 0 && len(failed) > 0 {
+		printLatencyRow("succeeded", computeLatencyStats(succeeded))
+		printLatencyRow("failed", computeLatencyStats(failed))
+	}
+}
+
+// printLatencyRow prints a single row of the latency table.
+func printLatencyRow(label string, stats LatencyStats) {
+	ms := func(v int64) string { return fmt.Sprintf("%dms", v) }
+	fmt.Printf("  %s %7s %7s %7s %7s %7s %7s\n",
+		color(colorDim, fmt.Sprintf("%-10s", label)),
+		ms(stats.AvgMs), ms(stats.P50Ms), ms(stats.P90Ms), ms(stats.P95Ms), ms(stats.P99Ms), ms(stats.MaxMs),
+	)
+}
+
+// printHistogram prints an ASCII bar chart of the latency histogram, limited
+// to the range of buckets that contain at least one delivery.
+func printHistogram(buckets []HistogramBucket) {
+	const barWidth = 24
+
+	first, last, peak := -1, -1, 0
+	for i, b := range buckets {
+		if b.Count == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		peak = max(peak, b.Count)
+	}
+	if first < 0 {
+		return
+	}
+
+	fmt.Println()
+	for i := first; i <= last; i++ {
+		b := buckets[i]
+		label := fmt.Sprintf("<=%dms", b.UpperMs)
+		if b.UpperMs == 0 {
+			label = fmt.Sprintf(">%dms", buckets[i-1].UpperMs)
+		}
+
+		width := b.Count * barWidth / peak
+		if b.Count > 0 && width == 0 {
+			width = 1
+		}
+		fmt.Printf("  %s %s %d\n",
+			color(colorDim, fmt.Sprintf("%10s", label)),
+			color(colorCyan, strings.Repeat("\u2588", width)),
+			b.Count,
+		)
+	}
+}
+
+// PrintTargetSummary prints delivery statistics per target. It prints
+// nothing for runs with a single target.
+func PrintTargetSummary(summaries []TargetSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Printf("  %s\n", color(colorBold, "Targets"))
+	fmt.Printf("  %s\n", color(colorDim, fmt.Sprintf("%-24s %16s %7s %7s %7s",
+		"Target", "Delivered", "p50", "p95", "max")))
+
+	for _, t := range summaries {
+		s := t.Summary
+		delivered := fmt.Sprintf("%d/%d (%.1f%%)", s.Succeeded, s.Total, s.SuccessRate*100)
+		deliveredColor := colorGreen
+		if s.Succeeded < s.Total {
+			deliveredColor = colorYellow
+		}
+		fmt.Printf("  %-24s %s %7s %7s %7s\n",
+			truncate(t.Name, 24),
+			color(deliveredColor, fmt.Sprintf("%16s", delivered)),
+			fmt.Sprintf("%dms", s.Latency.P50Ms),
+			fmt.Sprintf("%dms", s.Latency.P95Ms),
+			fmt.Sprintf("%dms", s.Latency.MaxMs),
+		)
+	}
+	fmt.Println()
+}
+
+// PrintChaosSummary prints, per injected fault type, how many faulted
+// deliveries the endpoint rejected and accepted, and whether that is the
+// correct behavior. It prints nothing when chaos mode is off.
+func PrintChaosSummary(stats []ChaosFaultStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Printf("  %s\n", color(colorBold, "Chaos"))
+	fmt.Printf("  %s\n", color(colorDim, fmt.Sprintf("%-16s %8s %8s %8s %6s  %s",
+		"Fault", "Injected", "Rejected", "Accepted", "Errors", "Result")))
+
+	for _, s := range stats {
+		verdict := color(colorGreen, "PASS")
+		if !s.Passed {
+			verdict = color(colorRed, "FAIL")
+		}
+		if s.Injected == 0 {
+			verdict = color(colorDim, "not injected")
+		}
+		fmt.Printf("  %-16s %8d %8d %8d %6d  %s %s\n",
+			s.Fault, s.Injected, s.Rejected, s.Accepted, s.Errors, verdict,
+			color(colorDim, "(expect "+s.Expect+")"),
 		)
 	}
+	fmt.Println()
+}
+
+// PrintListenBanner prints the startup banner for the listen command.
+func PrintListenBanner(version, url, file string) {
+	fmt.Println()
+	fmt.Printf("  %s\n", color(colorBold, "CertWatch Webhook CLI v"+version)+" "+color(colorDim, "-- Listen"))
+	fmt.Printf("  %s %s\n", color(colorDim, "Listening:"), color(colorCyan, url))
+	if file != "" {
+		fmt.Printf("  %s %s\n", color(colorDim, "Saving:   "), file)
+	}
+	fmt.Println()
+	fmt.Printf("  %s\n\n", color(colorDim, "Waiting for payloads..."))
+}
+
+// PrintReceived prints a single line for a payload received by the listener,
+// showing whether its signature verified.
+func PrintReceived(rec receivedPayload) {
+	index := fmt.Sprintf("#%-3d", rec.Index)
+	cn := rec.Payload.Data.CommonName
+	if cn == "" {
+		cn = "unknown"
+	}
+	cn = fmt.Sprintf("%-28s", truncate(cn, 28))
+
+	status := color(colorGreen, "VERIFIED")
+	if !rec.Verified {
+		status = color(colorRed, "FAILED "+rec.Error)
+	}
+
+	fmt.Printf("  %s %s %s %s  %s\n",
+		color(colorDim, index),
+		cn,
+		color(colorDim, "<-"),
+		status,
+		color(colorDim, rec.Payload.EventID),
+	)
+}
+
+// PrintListenSummary prints the final listener summary.
+func PrintListenSummary(received, verified, failed int) {
+	separator := strings.Repeat("\u2500", 36)
+
+	fmt.Println()
+	fmt.Printf("  %s\n", color(colorDim, separator))
+	fmt.Printf("  %s\n", color(colorBold, "Summary"))
+	fmt.Printf("  %s\n", color(colorDim, separator))
+
+	verifiedColor := colorGreen
+	if failed > 0 {
+		verifiedColor = colorYellow
+	}
 
+	fmt.Printf("  %s %s\n", color(colorDim, "Received: "), fmt.Sprintf("%d", received))
+	fmt.Printf("  %s %s\n", color(colorDim, "Verified: "), color(verifiedColor, fmt.Sprintf("%d", verified)))
+	if failed > 0 {
+		fmt.Printf("  %s %s\n", color(colorDim, "Failed:   "), color(colorRed, fmt.Sprintf("%d", failed)))
+	}
+	fmt.Println()
+}
+
+// PrintMockServerBanner prints the startup banner for the mock-server command.
+func PrintMockServerBanner(version, endpoint, source string, rate float64, duration time.Duration) {
+	fmt.Println()
+	fmt.Printf("  %s\n", color(colorBold, "CertWatch Webhook CLI v"+version)+" "+color(colorDim, "-- Mock Server"))
+	fmt.Printf("  %s %s\n", color(colorDim, "Endpoint:"), color(colorCyan, endpoint))
+	fmt.Printf("  %s %s\n", color(colorDim, "Source:  "), source)
+	fmt.Printf("  %s %g/s%s %s\n", color(colorDim, "Rate:    "), rate, color(colorDim, " · Stream:"), duration)
+	fmt.Println()
+	fmt.Printf("  %s\n", color(colorDim, "Use -api-endpoint "+endpoint+" to connect the CLI."))
 	fmt.Println()
 }
 
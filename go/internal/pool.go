@@ -0,0 +1,345 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deliveryJob is a single payload queued for delivery.
+type deliveryJob struct {
+	seq     int // Position in the queue; used to restore order in ordered mode.
+	index   int
+	payload WebhookPayload
+	target  int    // Index into the pool's delivery configs.
+	fault   string // Chaos fault to inject, if any.
+
+	// unsequenced marks a job held back by the out-of-order fault. It has no
+	// seq, and its outcome is emitted as soon as it completes, even in
+	// ordered mode, so that later outcomes never wait for it.
+	unsequenced bool
+
+	// batch holds the jobs sent together in one request when batching
+	// CloudEvents. A batch job has no payload of its own.
+	batch []deliveryJob
+	// final marks the partial batches flushed by Close, which are sent even
+	// if the context has been cancelled.
+	final bool
+}
+
+// deliveryOutcome is the result of processing a deliveryJob: the final
+// result plus any injected deliveries.
+type deliveryOutcome struct {
+	seq         int
+	unsequenced bool // The job was held back and takes no place in the ordered sequence.
+	payload     WebhookPayload
+	result      DeliveryResult
+	injected    []DeliveryResult // Extra faulted deliveries, such as duplicates.
+	skipped     bool             // The job was dropped because the context was cancelled.
+}
+
+// deliveryPool delivers queued payloads using a bounded number of concurrent
+// workers, decoupling delivery latency from reading the SSE stream. Every
+// payload is delivered once per delivery config, i.e. once per target.
+//
+// Attempts that will be retried are passed to retrying as soon as they
+// complete, from the worker that made them, so the retry delay is visible
+// while it elapses. Final outcomes are passed to emit from a single
+// goroutine, so emit never runs concurrently with itself. In ordered mode,
+// outcomes are emitted in the order the payloads were submitted, even when
+// later deliveries complete first. In unordered mode, outcomes are emitted as
+// soon as they complete.
+// In both modes deliveries start in submission order, but with more than one
+// worker they may reach the endpoint out of order.
+//
+// When a config batches CloudEvents, payloads for its target are collected
+// into batches that are delivered in a single request, once full or once
+// the oldest payload has waited CloudEventsOptions.BatchWait. Each payload
+// still gets its own outcome. Close sends any partial batches, even after the
+// context is cancelled, since their payloads have already been accepted.
+type deliveryPool struct {
+	ctx      context.Context
+	cfgs     []DeliveryConfig
+	jobs     chan deliveryJob
+	outcomes chan deliveryOutcome
+	workers  sync.WaitGroup
+	done     chan struct{}
+	nextSeq  int
+
+	retrying func(DeliveryResult) // Called for every attempt that will be retried; may be nil.
+
+	chaos *chaosInjector // Nil unless chaos mode is on.
+	held  []*deliveryJob // Per target, an out-of-order job waiting for its successor.
+
+	batchMu     sync.Mutex      // Guards batches and batchTimers, which Submit and the timers share.
+	batches     [][]deliveryJob // Per target, the jobs collected for the next batch.
+	batchTimers []*time.Timer   // Per target, the timer that flushes a partial batch; nil if none.
+}
+
+// newDeliveryPool starts workers delivery goroutines and a collector that
+// calls emit for each outcome. queueSize bounds the number of payloads
+// waiting for a free worker; Submit blocks once the queue is full. If chaos
+// is non-nil, faults are injected into submitted payloads. retrying, if
+// non-nil, is called concurrently by the workers with every attempt that is
+// about to be retried.
+func newDeliveryPool(ctx context.Context, cfgs []DeliveryConfig, workers, queueSize int, ordered bool, chaos *chaosInjector, retrying func(DeliveryResult), emit func(deliveryOutcome)) *deliveryPool {
+	workers = max(workers, 1)
+	queueSize = max(queueSize, 0)
+
+	p := &deliveryPool{
+		ctx:      ctx,
+		cfgs:     cfgs,
+		jobs:     make(chan deliveryJob, queueSize),
+		outcomes: make(chan deliveryOutcome, workers),
+		done:     make(chan struct{}),
+		retrying: retrying,
+		chaos:    chaos,
+		held:     make([]*deliveryJob, len(cfgs)),
+
+		batches:     make([][]deliveryJob, len(cfgs)),
+		batchTimers: make([]*time.Timer, len(cfgs)),
+	}
+
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+
+	go p.collect(ordered, emit)
+
+	return p
+}
+
+// Submit queues a payload for delivery to every target. It must only be
+// called from a single goroutine. It blocks while the queue is full, and
+// drops the payload if the context is cancelled first.
+func (p *deliveryPool) Submit(index int, payload WebhookPayload) {
+	for target := range p.cfgs {
+		job := deliveryJob{index: index, payload: payload, target: target}
+
+		if p.cfgs[target].CloudEvents.batchSize() > 0 {
+			job.seq = p.takeSeq()
+			p.addToBatch(job)
+			continue
+		}
+
+		if p.chaos != nil {
+			job.fault = p.chaos.draw()
+		}
+
+		// An out-of-order job is held back and queued right after the next
+		// job for the same target. It is left out of the ordered sequence,
+		// since that job may not arrive for a long time on a quiet stream.
+		if job.fault == FaultOutOfOrder && p.held[target] == nil {
+			job.unsequenced = true
+			p.held[target] = &job
+			continue
+		}
+
+		job.seq = p.takeSeq()
+		p.enqueue(job)
+		if held := p.held[target]; held != nil {
+			p.enqueue(*held)
+			p.held[target] = nil
+		}
+	}
+}
+
+// takeSeq returns the next position in the ordered sequence.
+func (p *deliveryPool) takeSeq() int {
+	seq := p.nextSeq
+	p.nextSeq++
+	return seq
+}
+
+// addToBatch adds job to the batch for its target, queueing the batch once
+// it is full. The first job of a batch starts a timer that queues the batch
+// after the configured wait if it has not filled up by then.
+func (p *deliveryPool) addToBatch(job deliveryJob) {
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+
+	target := job.target
+	ce := p.cfgs[target].CloudEvents
+	p.batches[target] = append(p.batches[target], job)
+	if len(p.batches[target]) >= ce.batchSize() {
+		p.flushBatch(target, false)
+		return
+	}
+	if ce.BatchWait > 0 && p.batchTimers[target] == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(ce.BatchWait, func() {
+			p.batchMu.Lock()
+			defer p.batchMu.Unlock()
+			// A stale timer may fire after its batch was flushed, and once
+			// the context is cancelled Close sends what is left.
+			if p.batchTimers[target] == timer && p.ctx.Err() == nil {
+				p.flushBatch(target, false)
+			}
+		})
+		p.batchTimers[target] = timer
+	}
+}
+
+// flushBatch queues the jobs collected for target as one batch job, marked
+// final if set. p.batchMu must be held.
+func (p *deliveryPool) flushBatch(target int, final bool) {
+	if timer := p.batchTimers[target]; timer != nil {
+		timer.Stop()
+		p.batchTimers[target] = nil
+	}
+	batch := p.batches[target]
+	if len(batch) == 0 {
+		return
+	}
+	p.batches[target] = nil
+	p.enqueue(deliveryJob{seq: batch[0].seq, target: target, batch: batch, final: final})
+}
+
+// enqueue sends job to the workers, giving up if the context is cancelled
+// unless job is final.
+func (p *deliveryPool) enqueue(job deliveryJob) {
+	if job.final {
+		p.jobs <- job
+		return
+	}
+	select {
+	case p.jobs <- job:
+	case <-p.ctx.Done():
+		// Keep ordered mode from waiting for a job that will never run.
+		p.skip(job)
+	}
+}
+
+// skip reports job, or every job in its batch, as skipped.
+func (p *deliveryPool) skip(job deliveryJob) {
+	if job.batch == nil {
+		p.outcomes <- deliveryOutcome{seq: job.seq, unsequenced: job.unsequenced, skipped: true}
+		return
+	}
+	for _, j := range job.batch {
+		p.outcomes <- deliveryOutcome{seq: j.seq, skipped: true}
+	}
+}
+
+// Close stops accepting jobs, waits for queued deliveries to finish, and
+// waits until every outcome has been emitted.
+func (p *deliveryPool) Close() {
+	p.batchMu.Lock()
+	for target := range p.batches {
+		p.flushBatch(target, true)
+	}
+	p.batchMu.Unlock()
+	for target, held := range p.held {
+		if held != nil {
+			p.enqueue(*held)
+			p.held[target] = nil
+		}
+	}
+	close(p.jobs)
+	p.workers.Wait()
+	close(p.outcomes)
+	<-p.done
+}
+
+// work delivers jobs until the queue is closed. Jobs still queued after the
+// context is cancelled are skipped rather than delivered, except final
+// batches.
+func (p *deliveryPool) work() {
+	defer p.workers.Done()
+
+	for job := range p.jobs {
+		if p.ctx.Err() != nil && !job.final {
+			p.skip(job)
+			continue
+		}
+		if job.batch != nil {
+			p.deliverBatch(job)
+			continue
+		}
+
+		out := deliveryOutcome{seq: job.seq, unsequenced: job.unsequenced, payload: job.payload}
+
+		cfg := p.cfgs[job.target]
+		switch job.fault {
+		case "", FaultDuplicate:
+			out.result = DeliverPayload(p.ctx, job.payload, cfg, job.index, p.onAttempt)
+			if job.fault == FaultDuplicate && p.ctx.Err() == nil {
+				dup := deliverFault(p.ctx, job.payload, cfg, job.index, FaultDuplicate, 0)
+				out.injected = append(out.injected, dup)
+			}
+		default:
+			out.result = deliverFault(p.ctx, job.payload, cfg, job.index, job.fault, p.chaos.opts.SlowDuration)
+		}
+		p.outcomes <- out
+	}
+}
+
+// onAttempt passes attempt to p.retrying if another attempt will follow.
+// Final attempts are reported through emit instead.
+func (p *deliveryPool) onAttempt(attempt DeliveryResult) {
+	if attempt.RetryIn > 0 && p.retrying != nil {
+		p.retrying(attempt)
+	}
+}
+
+// deliverBatch delivers the jobs of a batch job in one request and sends an
+// outcome for each of them.
+func (p *deliveryPool) deliverBatch(job deliveryJob) {
+	payloads := make([]WebhookPayload, len(job.batch))
+	indices := make([]int, len(job.batch))
+	outs := make([]deliveryOutcome, len(job.batch))
+	for i, j := range job.batch {
+		payloads[i], indices[i] = j.payload, j.index
+		outs[i] = deliveryOutcome{seq: j.seq, payload: j.payload}
+	}
+
+	// A final batch flushed after cancellation is sent once, without
+	// retries, so that it cannot hold up shutdown.
+	ctx, cfg := p.ctx, p.cfgs[job.target]
+	if job.final && ctx.Err() != nil {
+		ctx = context.WithoutCancel(ctx)
+		cfg.Retry.MaxAttempts = 1
+	}
+
+	results := DeliverBatch(ctx, payloads, indices, cfg, func(attempts []DeliveryResult) {
+		for _, attempt := range attempts {
+			p.onAttempt(attempt)
+		}
+	})
+	for i, out := range outs {
+		out.result = results[i]
+		p.outcomes <- out
+	}
+}
+
+// collect receives outcomes from the workers and passes them to emit,
+// restoring submission order first when ordered is set.
+func (p *deliveryPool) collect(ordered bool, emit func(deliveryOutcome)) {
+	defer close(p.done)
+
+	pending := make(map[int]deliveryOutcome)
+	next := 0
+
+	for out := range p.outcomes {
+		if !ordered || out.unsequenced {
+			if !out.skipped {
+				emit(out)
+			}
+			continue
+		}
+
+		pending[out.seq] = out
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if !ready.skipped {
+				emit(ready)
+			}
+		}
+	}
+}
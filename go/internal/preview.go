@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -36,17 +38,34 @@ func GenerateSamplePayload() WebhookPayload {
 }
 
 // PrintPreview renders a boxed preview of a sample POST request including
-// headers, JSON body, and HMAC-SHA256 signature computed from the secret.
-func PrintPreview(secret, version string) {
+// headers, JSON body, and the HMAC-SHA256 signatures computed from
+// cfg.Secret for cfg's header profile and signature scheme. The signatures
+// cover the body as delivered; the body is shown indented.
+func PrintPreview(cfg DeliveryConfig, version string) {
 	payload := GenerateSamplePayload()
 
-	body, err := json.MarshalIndent(payload, "  ", "  ")
+	// Sign the body exactly as a delivery would send it.
+	body, err := cfg.encodeBody(payload)
 	if err != nil {
-		PrintError(fmt.Sprintf("failed to marshal sample payload: %v", err))
+		PrintError(fmt.Sprintf("failed to encode sample payload: %v", err))
 		return
 	}
 
-	signature := SignPayload(string(body), secret)
+	headers, err := webhookHeaders(cfg, payload, string(body), time.Now())
+	if err != nil {
+		PrintError(fmt.Sprintf("failed to sign sample payload: %v", err))
+		return
+	}
+
+	// Indent the compact JSON for display only. Transform output is shown
+	// as rendered, since its layout is what the endpoint receives.
+	display := body
+	if cfg.Transform == nil || cfg.CloudEvents.Mode != "" {
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "  ", "  ") == nil {
+			display = buf.Bytes()
+		}
+	}
 
 	fmt.Println()
 	fmt.Printf("  %s\n", color(colorBold, "CertWatch Webhook CLI v"+version)+" "+color(colorDim, "-- Preview"))
@@ -61,17 +80,15 @@ func PrintPreview(secret, version string) {
 
 	// Headers.
 	fmt.Printf("  %s  %s\n", color(colorDim, "\u2502"), color(colorBold, "Headers:"))
-	printBoxLine("Content-Type: application/json")
-	printBoxLine("User-Agent: CertWatch-Webhook/1.0")
-	printBoxLine("X-CertWatch-Event-Id: " + payload.EventID)
-	printBoxLine("X-CertWatch-Timestamp: " + payload.Timestamp)
-	printBoxLine("X-CertWatch-Signature: sha256=" + signature)
+	for _, h := range headers {
+		printBoxLine(h.Name + ": " + h.Value)
+	}
 
 	fmt.Printf("  %s\n", color(colorDim, "\u2502"))
 
 	// Body.
 	fmt.Printf("  %s  %s\n", color(colorDim, "\u2502"), color(colorBold, "Body:"))
-	for _, line := range strings.Split(string(body), "\n") {
+	for _, line := range strings.Split(string(display), "\n") {
 		fmt.Printf("  %s    %s\n", color(colorDim, "\u2502"), line)
 	}
 
@@ -81,10 +98,25 @@ func PrintPreview(secret, version string) {
 	fmt.Printf("  %s\n", color(colorDim, "\u2514"+strings.Repeat("\u2500", boxWidth)))
 
 	fmt.Println()
-	fmt.Printf("  %s %s\n", color(colorDim, "Signing secret:"), secret)
+	fmt.Printf("  %s %s\n", color(colorDim, "Signing secret:"), cfg.Secret)
+	if cfg.NextSecret != "" {
+		fmt.Printf("  %s %s %s\n", color(colorDim, "Next secret:   "), cfg.NextSecret,
+			color(colorDim, "(rotation: "+cfg.Rotation+")"))
+	}
 	fmt.Println()
 	fmt.Printf("  %s\n", "Verify the signature in your endpoint:")
-	fmt.Printf("    %s\n", color(colorCyan, "HMAC-SHA256(JSON.stringify(body), secret) === signature"))
+	switch {
+	case cfg.Profile == HeaderProfileStandardWebhooks:
+	case cfg.SignatureScheme == SignatureSchemeTimestamped:
+		fmt.Printf("    %s\n", color(colorCyan, "HMAC-SHA256(t + \".\" + rawBody, secret) === v1"))
+		fmt.Printf("    %s\n", color(colorDim, "and reject requests whose t is older than your replay window"))
+	default:
+		fmt.Printf("    %s\n", color(colorCyan, "HMAC-SHA256(JSON.stringify(body), secret) === signature"))
+	}
+	if cfg.Profile == HeaderProfileStandardWebhooks || cfg.Profile == HeaderProfileBoth {
+		fmt.Printf("    %s\n", color(colorCyan, "base64(HMAC-SHA256(id + \".\" + timestamp + \".\" + rawBody, key)) === v1"))
+		fmt.Printf("    %s\n", color(colorDim, "or use any Standard Webhooks library; key is the base64-decoded whsec_ secret"))
+	}
 	fmt.Println()
 }
 
@@ -119,6 +151,16 @@ func randomHex(n int) string {
 	return hex.EncodeToString(b)
 }
 
+// randomStandardWebhooksSecret generates a "whsec_"-prefixed base64 secret
+// holding 24 random bytes using crypto/rand.
+func randomStandardWebhooksSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return standardWebhooksSecretPrefix + strings.Repeat("A", 32)
+	}
+	return standardWebhooksSecretPrefix + base64.StdEncoding.EncodeToString(b)
+}
+
 // randomSerialNumber generates n random bytes and returns them as an
 // uppercase colon-separated hex string (e.g., "AB:CD:EF:...").
 func randomSerialNumber(n int) string {
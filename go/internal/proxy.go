@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyDirect disables proxying when used as a proxy URL, including proxies
+// configured through the environment.
+const ProxyDirect = "direct"
+
+// ProxyOptions configures the outbound proxy for one kind of connection:
+// the CertWatch API and stream, or deliveries to targets.
+type ProxyOptions struct {
+	// URL is an http://, https://, or socks5:// proxy URL, optionally with
+	// credentials. Empty uses HTTP_PROXY, HTTPS_PROXY, and NO_PROXY from the
+	// environment; ProxyDirect connects directly.
+	URL string
+
+	// NoProxy is a comma-separated list of hosts that are reached directly:
+	// host names (which also match their subdomains), ".domain" suffixes,
+	// IP addresses, CIDR ranges, any of those with a ":port", or "*".
+	NoProxy string
+}
+
+// ValidateProxy checks the URL of o.
+func ValidateProxy(o ProxyOptions) error {
+	_, err := o.proxyURL()
+	return err
+}
+
+// proxyURL parses o.URL, returning nil when it is empty or ProxyDirect.
+func (o ProxyOptions) proxyURL() (*url.URL, error) {
+	if o.URL == "" || o.URL == ProxyDirect {
+		return nil, nil
+	}
+	u, err := url.Parse(o.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", o.URL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q (want http, https, or socks5)", u.Scheme)
+}
+
+// proxyFunc returns the http.Transport Proxy function for o. Loopback
+// targets are reached directly when the proxy comes from the environment,
+// as http.ProxyFromEnvironment does; an explicit o.URL proxies them too, so
+// a local proxy can be tested against a local endpoint.
+func (o ProxyOptions) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	fixed, err := o.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+	noProxy := parseNoProxy(o.NoProxy)
+
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy.matches(req.URL) {
+			return nil, nil
+		}
+		switch {
+		case fixed != nil:
+			return fixed, nil
+		case o.URL == ProxyDirect, isLoopbackHost(req.URL.Hostname()):
+			return nil, nil
+		default:
+			return http.ProxyFromEnvironment(req)
+		}
+	}, nil
+}
+
+// newAPITransport returns the transport for CertWatch API and stream
+// requests, proxied according to o.
+func newAPITransport(o ProxyOptions) (*http.Transport, error) {
+	proxy, err := o.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport, nil
+}
+
+// noProxyEntry is a single entry of a no-proxy list.
+type noProxyEntry struct {
+	all            bool       // "*" matches every host.
+	domain         string     // Matches the domain and its subdomains.
+	subdomainsOnly bool       // The domain was given with a leading dot.
+	cidr           *net.IPNet // Matches IP addresses in the range.
+	port           string     // Matches only this port, if set.
+}
+
+// noProxyList is a parsed no-proxy list.
+type noProxyList []noProxyEntry
+
+// parseNoProxy parses a comma-separated no-proxy list. Invalid entries are
+// ignored, as in the NO_PROXY environment variable.
+func parseNoProxy(s string) noProxyList {
+	var list noProxyList
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case part == "":
+			continue
+		case part == "*":
+			list = append(list, noProxyEntry{all: true})
+			continue
+		}
+
+		if _, cidr, err := net.ParseCIDR(part); err == nil {
+			list = append(list, noProxyEntry{cidr: cidr})
+			continue
+		}
+
+		host, port := part, ""
+		if h, p, err := net.SplitHostPort(part); err == nil {
+			host, port = h, p
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list = append(list, noProxyEntry{cidr: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, port: port})
+			continue
+		}
+
+		domain, subdomainsOnly := strings.CutPrefix(host, ".")
+		list = append(list, noProxyEntry{domain: domain, subdomainsOnly: subdomainsOnly, port: port})
+	}
+	return list
+}
+
+// matches reports whether u should be reached without a proxy.
+func (l noProxyList) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+
+	for _, e := range l {
+		if e.port != "" && e.port != port {
+			continue
+		}
+		switch {
+		case e.all:
+			return true
+		case e.cidr != nil:
+			if ip != nil && e.cidr.Contains(ip) {
+				return true
+			}
+		case host == e.domain:
+			if !e.subdomainsOnly {
+				return true
+			}
+		case strings.HasSuffix(host, "."+e.domain):
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNoProxyMatches(t *testing.T) {
+	tests := []struct {
+		noProxy string
+		url     string
+		want    bool
+	}{
+		{"", "http://example.com", false},
+		{"*", "http://example.com", true},
+		{" , ,", "http://example.com", false},
+
+		// Host names match themselves and their subdomains.
+		{"example.com", "http://example.com", true},
+		{"example.com", "http://api.example.com", true},
+		{"example.com", "http://notexample.com", false},
+		{"EXAMPLE.com", "http://Api.Example.COM", true},
+
+		// A leading dot matches subdomains only.
+		{".example.com", "http://example.com", false},
+		{".example.com", "http://api.example.com", true},
+		{".example.com", "http://a.b.example.com", true},
+
+		// Ports restrict an entry to that port, defaulting by scheme.
+		{"example.com:8080", "http://example.com:8080", true},
+		{"example.com:8080", "http://example.com", false},
+		{"example.com:443", "https://example.com", true},
+		{"example.com:80", "http://example.com", true},
+
+		// IP addresses match exactly, with or without a port.
+		{"10.0.0.1", "http://10.0.0.1:9000", true},
+		{"10.0.0.1", "http://10.0.0.2", false},
+		{"10.0.0.1:9000", "http://10.0.0.1:9000", true},
+		{"10.0.0.1:9000", "http://10.0.0.1:9001", false},
+		{"::1", "http://[::1]:3000", true},
+		{"[::1]:3000", "http://[::1]:3000", true},
+		{"[::1]:3000", "http://[::1]:3001", false},
+
+		// CIDR ranges match IP addresses inside them, but not host names.
+		{"192.168.0.0/16", "http://192.168.10.20", true},
+		{"192.168.0.0/16", "http://192.169.0.1", false},
+		{"fd00::/8", "http://[fd12::1]", true},
+		{"10.0.0.0/8", "http://ten.example.com", false},
+
+		// Entries are independent; any match is enough.
+		{"internal.test, 10.0.0.0/8", "http://10.1.2.3", true},
+		{"internal.test, 10.0.0.0/8", "http://svc.internal.test", true},
+		{"internal.test, 10.0.0.0/8", "http://example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.noProxy+" "+tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := parseNoProxy(tt.noProxy).matches(u); got != tt.want {
+				t.Errorf("parseNoProxy(%q).matches(%s) = %v, want %v", tt.noProxy, tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	// http.ProxyFromEnvironment reads the environment once per process, so
+	// this must be the only test that depends on it.
+	t.Setenv("HTTP_PROXY", "http://env-proxy:3128")
+	t.Setenv("NO_PROXY", "")
+
+	tests := []struct {
+		name string
+		opts ProxyOptions
+		url  string
+		want string // Proxy host, or "" for a direct connection.
+	}{
+		{"environment", ProxyOptions{}, "http://example.com", "env-proxy:3128"},
+		{"environment skips localhost", ProxyOptions{}, "http://localhost:3000", ""},
+		{"environment skips .localhost", ProxyOptions{}, "http://app.localhost:3000", ""},
+		{"environment skips loopback", ProxyOptions{}, "http://127.0.0.1:3000", ""},
+		{"environment with no-proxy", ProxyOptions{NoProxy: "example.com"}, "http://example.com", ""},
+		{"explicit", ProxyOptions{URL: "http://proxy:8080"}, "http://example.com", "proxy:8080"},
+		{"explicit proxies localhost", ProxyOptions{URL: "http://proxy:8080"}, "http://localhost:3000", "proxy:8080"},
+		{"explicit proxies loopback", ProxyOptions{URL: "socks5://proxy:1080"}, "http://[::1]:3000", "proxy:1080"},
+		{"explicit with no-proxy", ProxyOptions{URL: "http://proxy:8080", NoProxy: "localhost"}, "http://localhost:3000", ""},
+		{"direct", ProxyOptions{URL: ProxyDirect}, "http://example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, err := tt.opts.proxyFunc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(http.MethodPost, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if u != nil {
+				got = u.Host
+			}
+			if got != tt.want {
+				t.Errorf("proxy for %s = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
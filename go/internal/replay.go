@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LoadPayloads reads a JSONL file of webhook payloads, as written by -file.
+// Blank lines are ignored; a malformed line is reported with its line number.
+func LoadPayloads(path string) ([]WebhookPayload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck // read-only file close error is non-actionable
+
+	scanner := bufio.NewScanner(f)
+	// Allow up to 1 MB per line, matching the stream reader.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var payloads []WebhookPayload
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid payload: %w", path, lineNo, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return payloads, nil
+}
+
+// Replay re-delivers the payloads in opts.File to every target in
+// opts.Targets, re-signing each one with opts.Secret or the target's own
+// secret. Payloads are paced according to opts.Timing and the usual delivery
+// summary is printed at the end.
+func Replay(opts ReplayOptions, version string) error {
+	SetColor(!opts.NoColor)
+
+	payloads, err := LoadPayloads(opts.File)
+	if err != nil {
+		return err
+	}
+	if len(payloads) == 0 {
+		return fmt.Errorf("no payloads found in %s", opts.File)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	PrintBanner(version, targetURLs(opts.Targets), "Replay", 0)
+	PrintInfo(fmt.Sprintf("Replaying %d payloads from %s (%s)", len(payloads), opts.File, replayTimingLabel(opts)))
+	if opts.Chaos.enabled() {
+		PrintInfo("Chaos mode: " + chaosLabel(opts.Chaos))
+	}
+	if opts.CloudEvents.Mode != "" {
+		PrintInfo("Delivering CloudEvents (" + cloudEventsLabel(opts.CloudEvents) + ")")
+	}
+	if opts.Transform != nil {
+		PrintInfo("Transforming payloads with " + opts.Transform.Path)
+	}
+	if opts.Filter.Active() {
+		PrintInfo("Filter: " + opts.Filter.String())
+	}
+	fmt.Println()
+
+	// Filtered payloads are dropped before pacing, but keep their line
+	// number as their index.
+	filterStats := FilterStats{Expression: opts.Filter.String()}
+	var indices []int
+	selected := payloads[:0:0]
+	for i, payload := range payloads {
+		if !opts.Filter.Match(payload) {
+			filterStats.Filtered++
+			continue
+		}
+		filterStats.Matched++
+		selected = append(selected, payload)
+		indices = append(indices, i+1)
+	}
+
+	cfgs, err := opts.deliveryConfigs(opts.Targets, opts.Secret)
+	if err != nil {
+		return err
+	}
+
+	var results resultLog
+	pool := startDeliveries(ctx, cfgs, opts.DeliveryOptions, false, opts.Verbose, &results)
+
+	startTime := time.Now()
+	offsets := replayOffsets(selected, opts)
+
+	for i, payload := range selected {
+		if err := sleepContext(ctx, time.Until(startTime.Add(offsets[i]))); err != nil {
+			break
+		}
+		pool.Submit(indices[i], payload)
+	}
+
+	pool.Close()
+
+	elapsedMs := time.Since(startTime).Milliseconds()
+	finalResults, chaosStats := splitChaosResults(results.snapshot(), opts.Chaos)
+
+	targetSummaries := summarizeTargets(finalResults, opts.Targets)
+
+	PrintSummary(finalResults, elapsedMs)
+	if opts.Filter.Active() {
+		PrintFilterStats(filterStats)
+	}
+	PrintTargetSummary(targetSummaries)
+	PrintChaosSummary(chaosStats)
+
+	if opts.Report != "" {
+		report := BuildReport(version, "replay", targetURLs(opts.Targets), startTime, elapsedMs, nil, finalResults)
+		report.Targets = targetSummaries
+		report.Chaos = chaosStats
+		if opts.Filter.Active() {
+			report.Filter = &filterStats
+		}
+		if err := WriteReport(opts.Report, report); err != nil {
+			return err
+		}
+		PrintInfo("Report written to " + opts.Report)
+	}
+
+	if opts.JUnit != "" {
+		suites := BuildJUnit("certwatch-webhook-cli.replay", targetURLs(opts.Targets), startTime, elapsedMs, finalResults)
+		if err := WriteJUnit(opts.JUnit, suites); err != nil {
+			return err
+		}
+		PrintInfo("JUnit report written to " + opts.JUnit)
+	}
+
+	if ctx.Err() != nil {
+		PrintInfo("Interrupted by signal")
+	}
+
+	return checkForFailures(finalResults, chaosStats)
+}
+
+// replayOffsets returns, for each payload, the delay from the start of the
+// replay at which it should be submitted.
+//
+// In original mode the offsets follow the payload timestamps relative to the
+// first payload. Payloads with a missing or unparseable timestamp, or one
+// earlier than the previous payload, are sent right after the previous one.
+func replayOffsets(payloads []WebhookPayload, opts ReplayOptions) []time.Duration {
+	offsets := make([]time.Duration, len(payloads))
+
+	switch opts.Timing {
+	case ReplayTimingRate:
+		interval := time.Duration(float64(time.Second) / opts.Rate)
+		for i := range offsets {
+			offsets[i] = time.Duration(i) * interval
+		}
+
+	case ReplayTimingOriginal:
+		var first time.Time
+		for i, payload := range payloads {
+			if i > 0 {
+				offsets[i] = offsets[i-1]
+			}
+			ts, err := time.Parse(time.RFC3339, payload.Timestamp)
+			if err != nil {
+				continue
+			}
+			if first.IsZero() {
+				first = ts
+			}
+			if d := ts.Sub(first); d > offsets[i] {
+				offsets[i] = d
+			}
+		}
+	}
+
+	return offsets
+}
+
+// replayTimingLabel describes the replay timing mode for the banner.
+func replayTimingLabel(opts ReplayOptions) string {
+	switch opts.Timing {
+	case ReplayTimingOriginal:
+		return "original timing"
+	case ReplayTimingRate:
+		return fmt.Sprintf("%g/s", opts.Rate)
+	default:
+		return "as fast as possible"
+	}
+}
@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RunReport is the machine-readable report written by -report.
+type RunReport struct {
+	Version    string            `json:"version"`
+	Mode       string            `json:"mode"`
+	Target     string            `json:"target"`
+	StartedAt  time.Time         `json:"started_at"`
+	ElapsedMs  int64             `json:"elapsed_ms"`
+	Stream     *ReportStream     `json:"stream,omitempty"`
+	Summary    ReportSummary     `json:"summary"`
+	Targets    []TargetSummary   `json:"targets,omitempty"` // Per-target statistics when fanning out.
+	Chaos      []ChaosFaultStats `json:"chaos,omitempty"`   // Per-fault outcomes in chaos mode.
+	Filter     *FilterStats      `json:"filter,omitempty"`  // Payloads matched and skipped by -filter.
+	Deliveries []ReportDelivery  `json:"deliveries"`
+}
+
+// ReportStream describes the SSE stream a run consumed. It is omitted for
+// runs that did not read a stream, such as replay.
+type ReportStream struct {
+	TestID                string `json:"test_id,omitempty"`
+	StreamDurationSeconds int    `json:"stream_duration_seconds,omitempty"`
+	LastEventID           string `json:"last_event_id,omitempty"`
+	Reconnects            int    `json:"reconnects"`
+	Gaps                  int    `json:"gaps"`
+	Duplicates            int    `json:"duplicates"`
+}
+
+// ReportSummary holds aggregate delivery statistics.
+type ReportSummary struct {
+	Total       int          `json:"total"`
+	Succeeded   int          `json:"succeeded"`
+	Failed      int          `json:"failed"`
+	FirstTry    int          `json:"first_try"`
+	SuccessRate float64      `json:"success_rate"` // Fraction between 0 and 1.
+	Latency     LatencyStats `json:"latency"`
+	// Latency of successful and failed deliveries, respectively.
+	LatencySucceeded LatencyStats      `json:"latency_succeeded"`
+	LatencyFailed    LatencyStats      `json:"latency_failed"`
+	Histogram        []HistogramBucket `json:"histogram"`
+}
+
+// ReportDelivery is the final outcome of delivering a single payload.
+type ReportDelivery struct {
+	Target     string `json:"target,omitempty"`
+	Index      int    `json:"index"`
+	EventID    string `json:"event_id"`
+	CommonName string `json:"common_name"`
+	Status     int    `json:"status"`
+	StatusText string `json:"status_text,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+
+	ExpectationFailures []string `json:"expectation_failures,omitempty"`
+
+	ResponseHeaders       http.Header `json:"response_headers,omitempty"`
+	ResponseBody          string      `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool        `json:"response_body_truncated,omitempty"`
+
+	TLSVersion string `json:"tls_version,omitempty"`
+	TLSCipher  string `json:"tls_cipher,omitempty"`
+
+	Timing ReportTiming `json:"timing"`
+}
+
+// ReportTiming is the timing breakdown of a delivery's final attempt, in
+// fractional milliseconds.
+type ReportTiming struct {
+	DNSMs     float64 `json:"dns_ms"`
+	ConnectMs float64 `json:"connect_ms"`
+	TLSMs     float64 `json:"tls_ms"`
+	TTFBMs    float64 `json:"ttfb_ms"`
+	Reused    bool    `json:"reused"`
+}
+
+// BuildReport assembles a RunReport from the final delivery results of a run.
+// stream may be nil for runs that did not read a stream.
+func BuildReport(version, mode, target string, startedAt time.Time, elapsedMs int64, stream *ReportStream, results []DeliveryResult) RunReport {
+	report := RunReport{
+		Version:    version,
+		Mode:       mode,
+		Target:     target,
+		StartedAt:  startedAt.UTC(),
+		ElapsedMs:  elapsedMs,
+		Stream:     stream,
+		Deliveries: make([]ReportDelivery, 0, len(results)),
+	}
+
+	for _, r := range results {
+		report.Deliveries = append(report.Deliveries, ReportDelivery{
+			Target:     r.Target,
+			Index:      r.Index,
+			EventID:    r.EventID,
+			CommonName: r.CommonName,
+			Status:     r.Status,
+			StatusText: r.StatusText,
+			LatencyMs:  r.LatencyMs,
+			Success:    r.Success,
+			Error:      r.Error,
+			Attempts:   max(r.Attempt, 1),
+
+			ExpectationFailures: r.ExpectationFailures,
+
+			ResponseHeaders:       r.ResponseHeaders,
+			ResponseBody:          r.ResponseBody,
+			ResponseBodyTruncated: r.ResponseBodyTruncated,
+
+			TLSVersion: r.TLSVersion,
+			TLSCipher:  r.TLSCipher,
+
+			Timing: ReportTiming{
+				DNSMs:     durationMs(r.Timing.DNS),
+				ConnectMs: durationMs(r.Timing.Connect),
+				TLSMs:     durationMs(r.Timing.TLS),
+				TTFBMs:    durationMs(r.Timing.TTFB),
+				Reused:    r.Timing.Reused,
+			},
+		})
+	}
+	report.Summary = buildSummary(results)
+
+	return report
+}
+
+// buildSummary computes aggregate statistics for results.
+func buildSummary(results []DeliveryResult) ReportSummary {
+	summary := ReportSummary{Total: len(results)}
+	for _, r := range results {
+		if r.Success {
+			summary.Succeeded++
+			if r.Attempt <= 1 {
+				summary.FirstTry++
+			}
+		}
+	}
+	summary.Failed = summary.Total - summary.Succeeded
+	if summary.Total > 0 {
+		summary.SuccessRate = float64(summary.Succeeded) / float64(summary.Total)
+	}
+	summary.Latency = computeLatencyStats(results)
+	succeeded, failed := splitBySuccess(results)
+	summary.LatencySucceeded = computeLatencyStats(succeeded)
+	summary.LatencyFailed = computeLatencyStats(failed)
+	summary.Histogram = computeHistogram(results)
+	return summary
+}
+
+// WriteReport writes report to path as indented JSON.
+func WriteReport(path string, report RunReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backoff returns the delay to wait after the given (1-based) failed attempt.
+// A server-provided Retry-After delay takes precedence over the computed
+// backoff, up to MaxDelay, so a huge Retry-After cannot stall a worker.
+// Otherwise the delay doubles with each attempt, is capped at MaxDelay, and
+// uses "equal jitter": half of the delay is fixed and the other half is
+// random, which spreads retries without collapsing to zero.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return retryAfter
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// shouldRetry reports whether a delivery result is worth retrying. Both
+// network errors and unexpected (by default, non-2xx) statuses are retried,
+// matching CertWatch's production delivery semantics. Responses with an
+// expected status that fail other expectations are not: retrying will not
+// change the endpoint's contract, and neither are failures to encode, sign,
+// or build the request, which never reach the endpoint.
+func shouldRetry(result DeliveryResult) bool {
+	return !result.Success && !result.statusMet && !result.permanent
+}
+
+// parseRetryAfter parses a Retry-After header value, which may be either a
+// number of seconds or an HTTP date. It returns zero if the header is empty
+// or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is cancelled, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -17,7 +17,8 @@ import (
 // payload to the target URL, and prints a summary at the end.
 //
 // It also supports --preview (show sample and exit), --file (append JSONL),
-// and --raw (NDJSON to stdout). These modes are combinable with --url.
+// and --raw (NDJSON to stdout). These modes are combinable with --url, which
+// may name several targets that each receive every payload.
 func Run(opts CliOptions, version string) error {
 	SetColor(!opts.NoColor)
 
@@ -27,8 +28,20 @@ func Run(opts CliOptions, version string) error {
 		userProvidedSecret := secret != ""
 		if secret == "" {
 			secret = randomHex(32)
+			if opts.Profile == HeaderProfileStandardWebhooks {
+				secret = randomStandardWebhooksSecret()
+			}
 		}
-		PrintPreview(secret, version)
+		PrintPreview(DeliveryConfig{
+			Secret:          secret,
+			NextSecret:      opts.NextSecret,
+			Rotation:        opts.Rotation,
+			SignatureScheme: opts.SignatureScheme,
+			Profile:         opts.Profile,
+			Headers:         opts.requestHeaders(),
+			Transform:       opts.Transform,
+			CloudEvents:     opts.CloudEvents,
+		}, version)
 		if !userProvidedSecret {
 			fmt.Printf("  %s\n\n", color(colorDim, "Tip: pass -secret <your-secret> to preview with your real HMAC key"))
 		}
@@ -39,6 +52,17 @@ func Run(opts CliOptions, version string) error {
 	streamURL := ""
 	streamDuration := 0
 	mode := ""
+	testID := ""
+	reconnect := ReconnectPolicy{
+		MaxAttempts: opts.MaxReconnects,
+		BaseDelay:   opts.ReconnectDelay,
+		MaxDelay:    maxReconnectDelay,
+	}
+
+	apiTransport, err := newAPITransport(opts.APIProxy)
+	if err != nil {
+		return err
+	}
 
 	// Set up cancellable context for graceful shutdown on SIGINT/SIGTERM.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
@@ -52,7 +76,7 @@ func Run(opts CliOptions, version string) error {
 			PrintConnecting()
 		}
 
-		sess, err := CreateSession(ctx, opts.APIEndpoint, opts.APIKey, opts.Secret)
+		sess, err := CreateSession(ctx, opts.APIEndpoint, opts.APIKey, opts.Secret, apiTransport)
 		if err != nil {
 			if !opts.Raw {
 				fmt.Println() // newline after "Connecting..."
@@ -62,7 +86,11 @@ func Run(opts CliOptions, version string) error {
 
 		secret = sess.Data.Secret
 		streamURL = sess.Data.StreamURL
+		testID = sess.Data.TestID
 		streamDuration = sess.Data.StreamDurationSeconds
+		if sess.Data.ExpiresInSeconds > 0 {
+			reconnect.Deadline = time.Now().Add(time.Duration(sess.Data.ExpiresInSeconds) * time.Second)
+		}
 
 		if !opts.Raw {
 			PrintInfo("Signing secret: " + secret)
@@ -81,6 +109,23 @@ func Run(opts CliOptions, version string) error {
 		}
 	}
 
+	delivering := len(opts.Targets) > 0
+	if delivering && opts.Concurrency > 1 && !opts.Raw {
+		PrintInfo(fmt.Sprintf("Delivering with %d workers (%s)", opts.Concurrency, orderingLabel(opts.Unordered)))
+	}
+	if delivering && opts.Chaos.enabled() && !opts.Raw {
+		PrintInfo("Chaos mode: " + chaosLabel(opts.Chaos))
+	}
+	if delivering && opts.CloudEvents.Mode != "" && !opts.Raw {
+		PrintInfo("Delivering CloudEvents (" + cloudEventsLabel(opts.CloudEvents) + ")")
+	}
+	if delivering && opts.Transform != nil && !opts.Raw {
+		PrintInfo("Transforming payloads with " + opts.Transform.Path)
+	}
+	if opts.Filter.Active() && !opts.Raw {
+		PrintInfo("Filter: " + opts.Filter.String())
+	}
+
 	// Open JSONL file for appending if --file is set.
 	var outFile *os.File
 	if opts.File != "" {
@@ -94,23 +139,49 @@ func Run(opts CliOptions, version string) error {
 
 	var (
 		mu           sync.Mutex
-		results      []DeliveryResult
+		results      resultLog
 		index        int
 		filePayloads int
+		filterStats  = FilterStats{Expression: opts.Filter.String()}
 	)
 	startTime := time.Now()
 
+	// --url: deliveries run on a worker pool so a slow endpoint never stalls
+	// reading the stream.
+	var pool *deliveryPool
+	if delivering {
+		cfgs, err := opts.deliveryConfigs(opts.Targets, secret)
+		if err != nil {
+			return err
+		}
+		pool = startDeliveries(ctx, cfgs, opts.DeliveryOptions, opts.Raw, opts.Verbose, &results)
+	}
+
 	callbacks := StreamCallbacks{
 		OnMeta: func(meta StreamMeta) {
 			streamDuration = meta.StreamDurationSeconds
+			if meta.TestID != "" {
+				testID = meta.TestID
+			}
 		},
 
 		OnPayload: func(payload WebhookPayload) {
+			matched := opts.Filter.Match(payload)
 			mu.Lock()
 			index++
 			currentIndex := index
+			if matched {
+				filterStats.Matched++
+			} else {
+				filterStats.Filtered++
+			}
 			mu.Unlock()
 
+			// --filter: skip every output for payloads that do not match.
+			if !matched {
+				return
+			}
+
 			// --raw: write NDJSON to stdout.
 			if opts.Raw {
 				line, err := json.Marshal(payload)
@@ -130,21 +201,9 @@ func Run(opts CliOptions, version string) error {
 				}
 			}
 
-			// --url: deliver via HTTP.
-			if opts.URL != "" {
-				result := DeliverPayload(payload, opts.URL, secret, currentIndex)
-
-				if !opts.Raw {
-					PrintDelivery(result)
-				}
-
-				if opts.Verbose && !opts.Raw {
-					PrintVerbosePayload(payload)
-				}
-
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
+			// --url: queue for delivery via HTTP.
+			if pool != nil {
+				pool.Submit(currentIndex, payload)
 			} else if outFile != nil && !opts.Raw {
 				// File-only mode — show progress per payload.
 				PrintFileSaved(currentIndex, payload.Data.CommonName)
@@ -162,6 +221,12 @@ func Run(opts CliOptions, version string) error {
 				PrintError("Stream error: " + message)
 			}
 		},
+
+		OnReconnect: func(attempt int, delay time.Duration, cause error) {
+			if !opts.Raw {
+				PrintReconnect(attempt, delay, cause)
+			}
+		},
 	}
 
 	// If we're in API key mode, the "Connected" was already printed.
@@ -191,24 +256,77 @@ func Run(opts CliOptions, version string) error {
 		}
 	}
 
-	err := ConnectStream(ctx, streamURL, secret, callbacks)
+	stats, err := ConnectStream(ctx, streamURL, secret, reconnect, apiTransport, callbacks)
+
+	// Let queued deliveries finish before summarizing.
+	if pool != nil {
+		pool.Close()
+	}
 
 	elapsedMs := time.Since(startTime).Milliseconds()
 
+	finalResults, chaosStats := splitChaosResults(results.snapshot(), opts.Chaos)
+
 	mu.Lock()
-	finalResults := make([]DeliveryResult, len(results))
-	copy(finalResults, results)
 	finalFilePayloads := filePayloads
+	finalFilterStats := filterStats
 	mu.Unlock()
 
 	// Print file save summary.
 	if opts.File != "" && !opts.Raw {
 		PrintInfo(fmt.Sprintf("Saved %d payloads to %s", finalFilePayloads, opts.File))
 	}
+	if opts.Filter.Active() && !opts.Raw {
+		PrintFilterStats(finalFilterStats)
+	}
 
 	// Print delivery summary (only if we have URL deliveries and not in raw mode).
-	if !opts.Raw && opts.URL != "" {
+	targetSummaries := summarizeTargets(finalResults, opts.Targets)
+	if !opts.Raw && delivering {
 		PrintSummary(finalResults, elapsedMs)
+		PrintTargetSummary(targetSummaries)
+		PrintChaosSummary(chaosStats)
+	}
+
+	if !opts.Raw {
+		PrintStreamStats(stats)
+	}
+
+	if opts.Report != "" {
+		reportMode := "secret"
+		if opts.APIKey != "" {
+			reportMode = "api_key"
+		}
+		stream := &ReportStream{
+			TestID:                testID,
+			StreamDurationSeconds: streamDuration,
+			LastEventID:           stats.LastEventID,
+			Reconnects:            stats.Reconnects,
+			Gaps:                  stats.Gaps,
+			Duplicates:            stats.Duplicates,
+		}
+		report := BuildReport(version, reportMode, targetURLs(opts.Targets), startTime, elapsedMs, stream, finalResults)
+		report.Targets = targetSummaries
+		report.Chaos = chaosStats
+		if opts.Filter.Active() {
+			report.Filter = &finalFilterStats
+		}
+		if err := WriteReport(opts.Report, report); err != nil {
+			return err
+		}
+		if !opts.Raw {
+			PrintInfo("Report written to " + opts.Report)
+		}
+	}
+
+	if opts.JUnit != "" {
+		suites := BuildJUnit("certwatch-webhook-cli", targetURLs(opts.Targets), startTime, elapsedMs, finalResults)
+		if err := WriteJUnit(opts.JUnit, suites); err != nil {
+			return err
+		}
+		if !opts.Raw {
+			PrintInfo("JUnit report written to " + opts.JUnit)
+		}
 	}
 
 	// If the context was cancelled (SIGINT/SIGTERM), don't treat it as an error
@@ -218,21 +336,110 @@ func Run(opts CliOptions, version string) error {
 		if !opts.Raw {
 			PrintInfo("Interrupted by signal")
 		}
-		return checkForFailures(finalResults)
+		return checkForFailures(finalResults, chaosStats)
 	}
 
 	if err != nil && ctx.Err() == nil {
 		return fmt.Errorf("stream error: %w", err)
 	}
 
-	return checkForFailures(finalResults)
+	return checkForFailures(finalResults, chaosStats)
+}
+
+// maxReconnectDelay caps the backoff between stream reconnection attempts.
+const maxReconnectDelay = 30 * time.Second
+
+// resultLog collects final delivery results from concurrent producers.
+type resultLog struct {
+	mu      sync.Mutex
+	results []DeliveryResult
+}
+
+// add records a final delivery result.
+func (l *resultLog) add(result DeliveryResult) {
+	l.mu.Lock()
+	l.results = append(l.results, result)
+	l.mu.Unlock()
+}
+
+// snapshot returns a copy of the results recorded so far.
+func (l *resultLog) snapshot() []DeliveryResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]DeliveryResult, len(l.results))
+	copy(out, l.results)
+	return out
+}
+
+// startDeliveries starts a delivery pool for cfgs that prints every attempt
+// (unless quiet is set) and records each final result in log, including
+// the results of injected chaos faults. Attempts that will be retried are
+// printed as they happen; final results follow the pool's ordering.
+func startDeliveries(ctx context.Context, cfgs []DeliveryConfig, opts DeliveryOptions, quiet, verbose bool, log *resultLog) *deliveryPool {
+	chaos := newChaosInjector(opts.Chaos)
+	if verbose && !quiet {
+		for i := range cfgs {
+			cfgs[i].KeepResponse = true
+		}
+	}
+
+	// Workers print retries while the collector prints final results.
+	var printMu sync.Mutex
+	var retrying func(DeliveryResult)
+	if !quiet {
+		retrying = func(attempt DeliveryResult) {
+			printMu.Lock()
+			defer printMu.Unlock()
+			printAttempt(attempt, verbose)
+		}
+	}
+
+	return newDeliveryPool(ctx, cfgs, opts.Concurrency, opts.QueueSize, !opts.Unordered, chaos, retrying, func(out deliveryOutcome) {
+		if !quiet {
+			printMu.Lock()
+			defer printMu.Unlock()
+			printAttempt(out.result, verbose)
+			for _, injected := range out.injected {
+				printAttempt(injected, verbose)
+			}
+			if verbose {
+				PrintVerbosePayload(out.payload)
+			}
+		}
+		log.add(out.result)
+		for _, injected := range out.injected {
+			log.add(injected)
+		}
+	})
+}
+
+// printAttempt prints a delivery attempt, marking injected faults. In
+// verbose mode the endpoint's response follows.
+func printAttempt(result DeliveryResult, verbose bool) {
+	if result.Fault != "" {
+		PrintChaosDelivery(result)
+	} else {
+		PrintDelivery(result)
+	}
+	if verbose {
+		PrintVerboseResponse(result)
+	}
+}
+
+// retryPolicy returns the RetryPolicy described by the delivery options.
+func (o DeliveryOptions) retryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: o.MaxAttempts,
+		BaseDelay:   o.RetryDelay,
+		MaxDelay:    o.RetryMaxDelay,
+	}
 }
 
 // printStreamBanner prints the CLI banner with combined output targets.
 func printStreamBanner(version string, opts CliOptions, mode string, duration int) {
 	var targets []string
-	if opts.URL != "" {
-		targets = append(targets, opts.URL)
+	if len(opts.Targets) > 0 {
+		targets = append(targets, targetURLs(opts.Targets))
 	}
 	if opts.File != "" {
 		targets = append(targets, "file: "+opts.File)
@@ -245,13 +452,27 @@ func printStreamBanner(version string, opts CliOptions, mode string, duration in
 	PrintBanner(version, target, mode, duration)
 }
 
-// checkForFailures returns an error if any deliveries failed, suitable for
-// setting a non-zero exit code.
-func checkForFailures(results []DeliveryResult) error {
+// orderingLabel describes how delivery results are reported.
+func orderingLabel(unordered bool) string {
+	if unordered {
+		return "unordered"
+	}
+	return "ordered"
+}
+
+// checkForFailures returns an error if any deliveries failed or the endpoint
+// mishandled an injected chaos fault, suitable for setting a non-zero exit
+// code.
+func checkForFailures(results []DeliveryResult, chaos []ChaosFaultStats) error {
 	for _, r := range results {
 		if !r.Success {
 			return fmt.Errorf("some deliveries failed")
 		}
 	}
+	for _, c := range chaos {
+		if !c.Passed {
+			return fmt.Errorf("endpoint mishandled injected %s faults", c.Fault)
+		}
+	}
 	return nil
 }
@@ -2,17 +2,25 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptrace"
+	"strings"
 	"time"
 )
 
+// deliveryTimeout is the default overall limit for a delivery attempt.
 const deliveryTimeout = 10 * time.Second
 
+// maxResponseBodySize caps how much of a response body is captured.
+const maxResponseBodySize = 64 * 1024
+
 // SignPayload computes the HMAC-SHA256 signature of body using the provided
 // secret and returns the hex-encoded digest.
 func SignPayload(body, secret string) string {
@@ -21,42 +29,153 @@ func SignPayload(body, secret string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// DeliverPayload sends the webhook payload as a JSON POST to targetURL with
-// the appropriate CertWatch webhook headers and HMAC signature. It returns a
-// DeliveryResult describing the outcome.
-func DeliverPayload(payload WebhookPayload, targetURL, secret string, index int) DeliveryResult {
+// VerifySignature reports whether signatureHeader holds a valid
+// "sha256=<hex>" HMAC-SHA256 signature of body for the given secret. During
+// a secret rotation the header may carry several comma-separated
+// signatures, any of which may match. The comparison is constant-time.
+func VerifySignature(body []byte, signatureHeader, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	matched := false
+	for _, part := range strings.Split(signatureHeader, ",") {
+		provided, ok := strings.CutPrefix(strings.TrimSpace(part), "sha256=")
+		if !ok {
+			continue
+		}
+		providedMAC, err := hex.DecodeString(provided)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(expected, providedMAC) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// DeliverPayload sends the webhook payload as a JSON POST to cfg.URL with
+// the webhook headers and HMAC signatures selected by cfg.Profile, retrying
+// failed attempts according to cfg.Retry. If onAttempt is non-nil it is
+// called with the result of every attempt, including the last. The returned
+// DeliveryResult describes the final attempt.
+func DeliverPayload(ctx context.Context, payload WebhookPayload, cfg DeliveryConfig, index int, onAttempt func(DeliveryResult)) DeliveryResult {
+	return deliverWithRetry(ctx, cfg, func() DeliveryResult {
+		return deliverOnce(ctx, payload, cfg, index)
+	}, onAttempt)
+}
+
+// deliverWithRetry calls send until it succeeds or cfg.Retry gives up,
+// sleeping between attempts, and returns the result of the final attempt.
+func deliverWithRetry(ctx context.Context, cfg DeliveryConfig, send func() DeliveryResult, onAttempt func(DeliveryResult)) DeliveryResult {
+	maxAttempts := max(cfg.Retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		result := send()
+		result.Attempt = attempt
+
+		retry := shouldRetry(result) && attempt < maxAttempts && ctx.Err() == nil
+		if retry {
+			result.RetryIn = cfg.Retry.backoff(attempt, result.retryAfter)
+		}
+
+		if onAttempt != nil {
+			onAttempt(result)
+		}
+
+		if !retry {
+			return result
+		}
+		if err := sleepContext(ctx, result.RetryIn); err != nil {
+			result.RetryIn = 0
+			return result
+		}
+	}
+}
+
+// deliverOnce performs a single delivery attempt and returns its result.
+func deliverOnce(ctx context.Context, payload WebhookPayload, cfg DeliveryConfig, index int) DeliveryResult {
+	return sendPayload(ctx, payload, cfg, index, requestTamper{})
+}
+
+// requestTamper alters how a delivery request is built and sent. The zero
+// value sends a normal, correctly signed request.
+type requestTamper struct {
+	signedAt time.Time                            // Signing time; zero means now.
+	headers  func([]headerField) []headerField    // Rewrites the signed headers.
+	body     func(body []byte) (io.Reader, int64) // Replaces the body after signing.
+	client   *http.Client                         // Overrides the default client.
+}
+
+// sendPayload signs and sends payload once, applying t, and returns the
+// result of the attempt.
+func sendPayload(ctx context.Context, payload WebhookPayload, cfg DeliveryConfig, index int, t requestTamper) DeliveryResult {
 	result := DeliveryResult{
+		Target:     cfg.Target,
 		Index:      index,
+		EventID:    payload.EventID,
 		CommonName: payload.Data.CommonName,
 	}
 
-	body, err := json.Marshal(payload)
+	body, err := cfg.encodeBody(payload)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to marshal payload: %v", err)
+		result.Error = err.Error()
+		result.permanent = true
 		return result
 	}
+	return sendBody(ctx, payload, body, cfg, result, t)
+}
 
-	signature := SignPayload(string(body), secret)
+// sendBody signs and sends body once as the delivery of payload, applying t,
+// and fills in result with the outcome of the attempt.
+func sendBody(ctx context.Context, payload WebhookPayload, body []byte, cfg DeliveryConfig, result DeliveryResult, t requestTamper) DeliveryResult {
+	signedAt := t.signedAt
+	if signedAt.IsZero() {
+		signedAt = time.Now()
+	}
+	headers, err := webhookHeaders(cfg, payload, string(body), signedAt)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to sign payload: %v", err)
+		result.permanent = true
+		return result
+	}
+	if t.headers != nil {
+		headers = t.headers(headers)
+	}
 
-	req, err := http.NewRequest(http.MethodPost, targetURL, bytes.NewReader(body))
+	var reader io.Reader = bytes.NewReader(body)
+	contentLength := int64(len(body))
+	if t.body != nil {
+		reader, contentLength = t.body(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, reader)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
+		result.permanent = true
 		return result
 	}
+	req.ContentLength = contentLength
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "CertWatch-Webhook/1.0")
-	req.Header.Set("X-CertWatch-Event-Id", payload.EventID)
-	req.Header.Set("X-CertWatch-Timestamp", payload.Timestamp)
-	req.Header.Set("X-CertWatch-Signature", "sha256="+signature)
+	for _, h := range headers {
+		req.Header.Set(h.Name, h.Value)
+	}
 
-	client := &http.Client{Timeout: deliveryTimeout}
+	client := t.client
+	if client == nil {
+		client = cfg.client()
+	}
 
+	timing := &timingRecorder{}
 	start := time.Now()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timing.trace()))
+
 	resp, err := client.Do(req)
 	elapsed := time.Since(start)
 
 	result.LatencyMs = elapsed.Milliseconds()
+	result.Timing = timing.result()
 
 	if err != nil {
 		result.Error = fmt.Sprintf("delivery failed: %v", err)
@@ -66,11 +185,98 @@ func DeliverPayload(payload WebhookPayload, targetURL, secret string, index int)
 
 	result.Status = resp.StatusCode
 	result.StatusText = http.StatusText(resp.StatusCode)
-	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	result.TLSVersion, result.TLSCipher = negotiatedTLS(resp.TLS)
+	result.statusMet = cfg.Expect.statusMet(resp.StatusCode)
 
-	if !result.Success {
+	respBody, truncated, err := readResponseBody(resp.Body)
+	result.ResponseHeaders = resp.Header
+	result.ResponseBody = string(respBody)
+	result.ResponseBodyTruncated = truncated
+
+	if err != nil {
+		// Retry a broken response like any other transport failure.
+		result.statusMet = false
+		result.Error = fmt.Sprintf("failed to read response: %v", err)
+	} else if !result.statusMet {
 		result.Error = fmt.Sprintf("received status %d %s", resp.StatusCode, result.StatusText)
+		if len(cfg.Expect.Statuses) > 0 {
+			result.Error += ", want " + cfg.Expect.statusLabel()
+		}
+	} else if failures := cfg.Expect.check(elapsed, resp.Header, respBody); len(failures) > 0 {
+		result.ExpectationFailures = failures
+		result.Error = "expectation failed: " + strings.Join(failures, "; ")
+	} else {
+		result.Success = true
+	}
+
+	// Successful responses are only worth their memory when printed or
+	// matched against, since every result is kept until the run ends.
+	if result.Success && !cfg.KeepResponse && len(cfg.Expect.Body) == 0 {
+		result.ResponseHeaders = nil
+		result.ResponseBody = ""
+		result.ResponseBodyTruncated = false
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		result.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 	}
 
 	return result
 }
+
+// encodeBody returns the request body for a single delivery of payload: its
+// data, wrapped in a CloudEvent in the structured and batch modes.
+func (cfg DeliveryConfig) encodeBody(payload WebhookPayload) ([]byte, error) {
+	switch cfg.CloudEvents.Mode {
+	case CloudEventsStructured:
+		data, err := cfg.encodeData(payload)
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(cfg.CloudEvents.event(payload, data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		return body, nil
+	case CloudEventsBatch:
+		return cfg.encodeBatch([]WebhookPayload{payload})
+	}
+	return cfg.encodeData(payload)
+}
+
+// encodeData returns the data delivered for payload: the output of
+// cfg.Transform if set, otherwise the payload as JSON, or only its Data when
+// delivering CloudEvents, whose attributes carry the envelope.
+func (cfg DeliveryConfig) encodeData(payload WebhookPayload) ([]byte, error) {
+	if cfg.Transform != nil {
+		return cfg.Transform.Render(payload)
+	}
+	var v any = payload
+	if cfg.CloudEvents.Mode != "" {
+		v = payload.Data
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return data, nil
+}
+
+// client returns the HTTP client deliveries for cfg are sent with.
+func (cfg DeliveryConfig) client() *http.Client {
+	if cfg.Client != nil {
+		return cfg.Client
+	}
+	return &http.Client{Timeout: deliveryTimeout}
+}
+
+// readResponseBody reads up to maxResponseBodySize bytes of body and reports
+// whether the body was longer than that. On a read error it returns what was
+// read before the error.
+func readResponseBody(body io.Reader) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxResponseBodySize+1))
+	if len(data) > maxResponseBodySize {
+		return data[:maxResponseBodySize], true, err
+	}
+	return data, false, err
+}
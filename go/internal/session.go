@@ -15,7 +15,8 @@ const sessionPath = "/api/v1/tools/webhook-test/session"
 // It returns the session response containing the stream URL, secret, and duration,
 // or an error if the request fails. If userSecret is non-empty, it is sent to the
 // backend so the session uses the caller's signing secret instead of a random one.
-func CreateSession(ctx context.Context, apiEndpoint, apiKey, userSecret string) (*SessionResponse, error) {
+// The request is sent through transport, or http.DefaultTransport if it is nil.
+func CreateSession(ctx context.Context, apiEndpoint, apiKey, userSecret string, transport http.RoundTripper) (*SessionResponse, error) {
 	url := apiEndpoint + sessionPath
 
 	var bodyReader *bytes.Reader
@@ -37,7 +38,7 @@ func CreateSession(ctx context.Context, apiEndpoint, apiKey, userSecret string)
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Timeout: 15 * time.Second, Transport: transport}
 
 	resp, err := client.Do(req)
 	if err != nil {
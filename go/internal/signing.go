@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by signature verification.
+var (
+	errMalformedSignature = errors.New("malformed signature header")
+	errSignatureMismatch  = errors.New("signature mismatch")
+	errStaleSignature     = errors.New("signature timestamp outside tolerance")
+)
+
+// standardWebhooksSecretPrefix marks a base64-encoded Standard Webhooks
+// signing secret.
+const standardWebhooksSecretPrefix = "whsec_"
+
+// SignTimestamped computes the hex-encoded HMAC-SHA256 of "<timestamp>.<body>"
+// using the provided secret.
+func SignTimestamped(body string, timestamp int64, secret string) string {
+	return SignPayload(strconv.FormatInt(timestamp, 10)+"."+body, secret)
+}
+
+// SignatureHeader returns the X-CertWatch-Signature value for body under the
+// given scheme, with one signature per secret: "sha256=<a>,sha256=<b>" or
+// "t=<unix>,v1=<a>,v1=<b>". now is the signing time used by
+// SignatureSchemeTimestamped.
+func SignatureHeader(scheme, body string, secrets []string, now time.Time) string {
+	var parts []string
+	if scheme == SignatureSchemeTimestamped {
+		t := now.Unix()
+		parts = append(parts, fmt.Sprintf("t=%d", t))
+		for _, secret := range secrets {
+			parts = append(parts, "v1="+SignTimestamped(body, t, secret))
+		}
+	} else {
+		for _, secret := range secrets {
+			parts = append(parts, "sha256="+SignPayload(body, secret))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// signingSecrets returns the secrets deliveries are signed with, according to
+// cfg.Rotation. Without a NextSecret only Secret is used.
+func (cfg DeliveryConfig) signingSecrets() []string {
+	switch {
+	case cfg.NextSecret == "" || cfg.Rotation == RotationOld:
+		return []string{cfg.Secret}
+	case cfg.Rotation == RotationNew:
+		return []string{cfg.NextSecret}
+	default:
+		return []string{cfg.Secret, cfg.NextSecret}
+	}
+}
+
+// VerifyTimestampedSignature checks a "t=<unix>,v1=<hex>" signature header
+// against body and secret. Any of several v1 values may match. The
+// timestamp must be within tolerance of now in either direction; a zero
+// tolerance disables the check. The comparison is constant-time.
+func VerifyTimestampedSignature(body []byte, signatureHeader, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signatures [][]byte
+	haveTimestamp := false
+
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return errMalformedSignature
+		}
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errMalformedSignature
+			}
+			timestamp, haveTimestamp = t, true
+		case "v1":
+			mac, err := hex.DecodeString(value)
+			if err != nil {
+				return errMalformedSignature
+			}
+			signatures = append(signatures, mac)
+		}
+	}
+	if !haveTimestamp || len(signatures) == 0 {
+		return errMalformedSignature
+	}
+
+	expected, err := hex.DecodeString(SignTimestamped(string(body), timestamp, secret))
+	if err != nil {
+		return err
+	}
+
+	matched := false
+	for _, sig := range signatures {
+		if hmac.Equal(expected, sig) {
+			matched = true
+		}
+	}
+	if !matched {
+		return errSignatureMismatch
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return errStaleSignature
+		}
+	}
+	return nil
+}
+
+// standardWebhooksKey returns the HMAC key for a Standard Webhooks secret.
+// Secrets with the "whsec_" prefix are base64-decoded; any other secret is
+// used as-is, matching how the X-CertWatch-Signature key is derived.
+func standardWebhooksKey(secret string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(secret, standardWebhooksSecretPrefix)
+	if !ok {
+		return []byte(secret), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s secret: %w", standardWebhooksSecretPrefix, err)
+	}
+	return key, nil
+}
+
+// SignStandardWebhooks returns the Standard Webhooks signature of body as
+// "v1,<base64>", where the HMAC-SHA256 covers "<id>.<timestamp>.<body>".
+func SignStandardWebhooks(id string, timestamp int64, body, secret string) (string, error) {
+	key, err := standardWebhooksKey(secret)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%d.%s", id, timestamp, body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyStandardWebhooks checks the webhook-signature header of a Standard
+// Webhooks request. signatureHeader may hold several space-separated
+// signatures; any matching "v1" signature is accepted. The timestamp must be
+// within tolerance of now; a zero tolerance disables the check.
+func VerifyStandardWebhooks(body []byte, id, timestamp, signatureHeader, secret string, tolerance time.Duration, now time.Time) error {
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if id == "" || err != nil {
+		return errMalformedSignature
+	}
+
+	expected, err := SignStandardWebhooks(id, t, string(body), secret)
+	if err != nil {
+		return err
+	}
+
+	matched := false
+	for _, sig := range strings.Fields(signatureHeader) {
+		if hmac.Equal([]byte(expected), []byte(sig)) {
+			matched = true
+		}
+	}
+	if !matched {
+		return errSignatureMismatch
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(t, 0))
+		if age > tolerance || age < -tolerance {
+			return errStaleSignature
+		}
+	}
+	return nil
+}
+
+// headerField is a single HTTP header. Headers are kept as an ordered list so
+// the preview shows them in the order they are set.
+type headerField struct {
+	Name  string
+	Value string
+}
+
+// webhookHeaders returns the headers sent with a signed delivery of body for
+// the given payload, according to cfg.Profile and cfg.SignatureScheme and
+// including any CloudEvents attribute headers, followed by cfg.Headers.
+func webhookHeaders(cfg DeliveryConfig, payload WebhookPayload, body string, now time.Time) ([]headerField, error) {
+	headers := []headerField{
+		{"Content-Type", cfg.CloudEvents.contentType()},
+		{"User-Agent", "CertWatch-Webhook/1.0"},
+	}
+	headers = append(headers, cfg.CloudEvents.binaryHeaders(payload)...)
+
+	secrets := cfg.signingSecrets()
+
+	if cfg.Profile != HeaderProfileStandardWebhooks {
+		headers = append(headers,
+			headerField{"X-CertWatch-Event-Id", payload.EventID},
+			headerField{"X-CertWatch-Timestamp", payload.Timestamp},
+			headerField{"X-CertWatch-Signature", SignatureHeader(cfg.SignatureScheme, body, secrets, now)},
+		)
+	}
+
+	if cfg.Profile == HeaderProfileStandardWebhooks || cfg.Profile == HeaderProfileBoth {
+		signatures := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			signature, err := SignStandardWebhooks(payload.EventID, now.Unix(), body, secret)
+			if err != nil {
+				return nil, err
+			}
+			signatures = append(signatures, signature)
+		}
+		headers = append(headers,
+			headerField{"webhook-id", payload.EventID},
+			headerField{"webhook-timestamp", strconv.FormatInt(now.Unix(), 10)},
+			headerField{"webhook-signature", strings.Join(signatures, " ")},
+		)
+	}
+
+	headers = append(headers, cfg.Headers...)
+
+	return headers, nil
+}
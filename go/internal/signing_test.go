@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The example from the Standard Webhooks specification.
+const (
+	standardWebhooksTestSecret    = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	standardWebhooksTestID        = "msg_p5jXN8AQM9LWM0D4loKWxJek"
+	standardWebhooksTestTimestamp = 1614265330
+	standardWebhooksTestBody      = `{"test": 2432232314}`
+	standardWebhooksTestSignature = "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+)
+
+func TestSignStandardWebhooks(t *testing.T) {
+	got, err := SignStandardWebhooks(standardWebhooksTestID, standardWebhooksTestTimestamp, standardWebhooksTestBody, standardWebhooksTestSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != standardWebhooksTestSignature {
+		t.Errorf("SignStandardWebhooks() = %q, want %q", got, standardWebhooksTestSignature)
+	}
+
+	if _, err := SignStandardWebhooks(standardWebhooksTestID, standardWebhooksTestTimestamp, standardWebhooksTestBody, "whsec_not base64!"); err == nil {
+		t.Error("SignStandardWebhooks() with an invalid whsec_ secret succeeded")
+	}
+}
+
+func TestVerifyStandardWebhooks(t *testing.T) {
+	signedAt := time.Unix(standardWebhooksTestTimestamp, 0)
+	timestamp := strconv.Itoa(standardWebhooksTestTimestamp)
+
+	tests := []struct {
+		name      string
+		body      string
+		id        string
+		timestamp string
+		signature string
+		secret    string
+		tolerance time.Duration
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", now: signedAt},
+		{name: "within tolerance", tolerance: 5 * time.Minute, now: signedAt.Add(5 * time.Minute)},
+		{name: "within tolerance in the future", tolerance: 5 * time.Minute, now: signedAt.Add(-5 * time.Minute)},
+		{name: "too old", tolerance: 5 * time.Minute, now: signedAt.Add(5*time.Minute + time.Second), wantErr: errStaleSignature},
+		{name: "too new", tolerance: 5 * time.Minute, now: signedAt.Add(-5*time.Minute - time.Second), wantErr: errStaleSignature},
+		{name: "zero tolerance skips the age check", now: signedAt.Add(24 * time.Hour)},
+		{name: "one of several signatures", signature: "v1,bm90IGl0 " + standardWebhooksTestSignature + " v2,abc", now: signedAt},
+		{name: "tampered body", body: `{"test": 2432232315}`, now: signedAt, wantErr: errSignatureMismatch},
+		{name: "different id", id: "msg_other", now: signedAt, wantErr: errSignatureMismatch},
+		{name: "different timestamp", timestamp: strconv.Itoa(standardWebhooksTestTimestamp + 1), now: signedAt, wantErr: errSignatureMismatch},
+		{name: "wrong secret", secret: "whsec_" + "c2VjcmV0", now: signedAt, wantErr: errSignatureMismatch},
+		{name: "missing signature", signature: " ", now: signedAt, wantErr: errSignatureMismatch},
+		{name: "blank id", id: " ", now: signedAt, wantErr: errSignatureMismatch},
+		{name: "invalid timestamp", timestamp: "yesterday", now: signedAt, wantErr: errMalformedSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, id, ts, signature, secret := standardWebhooksTestBody, standardWebhooksTestID, timestamp, standardWebhooksTestSignature, standardWebhooksTestSecret
+			if tt.body != "" {
+				body = tt.body
+			}
+			if tt.id != "" {
+				id = tt.id
+			}
+			if tt.timestamp != "" {
+				ts = tt.timestamp
+			}
+			if tt.signature != "" {
+				signature = tt.signature
+			}
+			if tt.secret != "" {
+				secret = tt.secret
+			}
+
+			err := VerifyStandardWebhooks([]byte(body), id, ts, signature, secret, tt.tolerance, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyStandardWebhooks() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyTimestampedSignature(t *testing.T) {
+	const body, secret = `{"event":"ct.certificate.new"}`, "s3cret"
+	signedAt := time.Unix(1767225600, 0)
+	ts := strconv.FormatInt(signedAt.Unix(), 10)
+	v1 := SignTimestamped(body, signedAt.Unix(), secret)
+	other := SignTimestamped(body, signedAt.Unix(), "other")
+
+	tests := []struct {
+		name      string
+		header    string
+		body      string
+		tolerance time.Duration
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", header: "t=" + ts + ",v1=" + v1, now: signedAt},
+		{name: "fields in any order with spaces", header: " v1=" + v1 + " , t=" + ts, now: signedAt},
+		{name: "one of several v1", header: "t=" + ts + ",v1=" + other + ",v1=" + v1, now: signedAt},
+		{name: "unknown fields are ignored", header: "t=" + ts + ",v0=abc,v1=" + v1, now: signedAt},
+		{name: "within tolerance", header: "t=" + ts + ",v1=" + v1, tolerance: 5 * time.Minute, now: signedAt.Add(5 * time.Minute)},
+		{name: "too old", header: "t=" + ts + ",v1=" + v1, tolerance: 5 * time.Minute, now: signedAt.Add(5*time.Minute + time.Second), wantErr: errStaleSignature},
+		{name: "too new", header: "t=" + ts + ",v1=" + v1, tolerance: 5 * time.Minute, now: signedAt.Add(-5*time.Minute - time.Second), wantErr: errStaleSignature},
+		{name: "zero tolerance skips the age check", header: "t=" + ts + ",v1=" + v1, now: signedAt.Add(-24 * time.Hour)},
+		{name: "wrong v1", header: "t=" + ts + ",v1=" + other, now: signedAt, wantErr: errSignatureMismatch},
+		{name: "timestamp not signed", header: "t=" + strconv.FormatInt(signedAt.Unix()+1, 10) + ",v1=" + v1, now: signedAt, wantErr: errSignatureMismatch},
+		{name: "tampered body", header: "t=" + ts + ",v1=" + v1, body: body + " ", now: signedAt, wantErr: errSignatureMismatch},
+		{name: "empty", header: "", now: signedAt, wantErr: errMalformedSignature},
+		{name: "missing t", header: "v1=" + v1, now: signedAt, wantErr: errMalformedSignature},
+		{name: "missing v1", header: "t=" + ts, now: signedAt, wantErr: errMalformedSignature},
+		{name: "non-numeric t", header: "t=now,v1=" + v1, now: signedAt, wantErr: errMalformedSignature},
+		{name: "non-hex v1", header: "t=" + ts + ",v1=zz", now: signedAt, wantErr: errMalformedSignature},
+		{name: "field without =", header: "t=" + ts + ",v1", now: signedAt, wantErr: errMalformedSignature},
+		{name: "body scheme header", header: "sha256=" + SignPayload(body, secret), now: signedAt, wantErr: errMalformedSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := body
+			if tt.body != "" {
+				b = tt.body
+			}
+			err := VerifyTimestampedSignature([]byte(b), tt.header, secret, tt.tolerance, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyTimestampedSignature(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignatureHeaderRotation(t *testing.T) {
+	const body = `{"event":"ct.certificate.new"}`
+	now := time.Unix(1767225600, 0)
+	oldBody, newBody := SignPayload(body, "old"), SignPayload(body, "new")
+	oldTS, newTS := SignTimestamped(body, now.Unix(), "old"), SignTimestamped(body, now.Unix(), "new")
+
+	tests := []struct {
+		scheme  string
+		secrets []string
+		want    string
+	}{
+		{SignatureSchemeBody, []string{"old"}, "sha256=" + oldBody},
+		{SignatureSchemeBody, []string{"old", "new"}, "sha256=" + oldBody + ",sha256=" + newBody},
+		{SignatureSchemeTimestamped, []string{"new"}, "t=1767225600,v1=" + newTS},
+		{SignatureSchemeTimestamped, []string{"old", "new"}, "t=1767225600,v1=" + oldTS + ",v1=" + newTS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme+"/"+strings.Join(tt.secrets, "+"), func(t *testing.T) {
+			got := SignatureHeader(tt.scheme, body, tt.secrets, now)
+			if got != tt.want {
+				t.Errorf("SignatureHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigningSecrets(t *testing.T) {
+	tests := []struct {
+		next, rotation string
+		want           []string
+	}{
+		{"", RotationBoth, []string{"old"}},
+		{"", RotationNew, []string{"old"}},
+		{"new", RotationOld, []string{"old"}},
+		{"new", RotationNew, []string{"new"}},
+		{"new", RotationBoth, []string{"old", "new"}},
+	}
+
+	for _, tt := range tests {
+		cfg := DeliveryConfig{Secret: "old", NextSecret: tt.next, Rotation: tt.rotation}
+		if got := cfg.signingSecrets(); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("signingSecrets() with next %q and rotation %s = %q, want %q", tt.next, tt.rotation, got, tt.want)
+		}
+	}
+}
+
+// TestListenerVerifyRotation checks that the listener accepts deliveries
+// signed during a rotation with either of its secrets, under every header
+// profile and signature scheme.
+func TestListenerVerifyRotation(t *testing.T) {
+	payload := GenerateSamplePayload()
+	const body = `{"event":"ct.certificate.new"}`
+
+	tests := []struct {
+		name               string
+		rotation           string // Which of "old" and "new" the sender signs with.
+		secret, nextSecret string // The listener's secrets.
+		wantErr            bool
+	}{
+		{name: "old to old", rotation: RotationOld, secret: "old"},
+		{name: "both to old", rotation: RotationBoth, secret: "old"},
+		{name: "both to new", rotation: RotationBoth, secret: "new"},
+		{name: "new to old and new", rotation: RotationNew, secret: "old", nextSecret: "new"},
+		{name: "old to old and new", rotation: RotationOld, secret: "old", nextSecret: "new"},
+		{name: "old to new and old", rotation: RotationOld, secret: "new", nextSecret: "old"},
+		{name: "new to old", rotation: RotationNew, secret: "old", wantErr: true},
+		{name: "old to new", rotation: RotationOld, secret: "new", wantErr: true},
+		{name: "both to other", rotation: RotationBoth, secret: "other", nextSecret: "another", wantErr: true},
+	}
+
+	for _, profile := range []string{HeaderProfileCertWatch, HeaderProfileStandardWebhooks, HeaderProfileBoth} {
+		for _, scheme := range []string{SignatureSchemeBody, SignatureSchemeTimestamped} {
+			for _, tt := range tests {
+				t.Run(profile+"/"+scheme+"/"+tt.name, func(t *testing.T) {
+					cfg := DeliveryConfig{
+						Secret:          "old",
+						NextSecret:      "new",
+						Rotation:        tt.rotation,
+						SignatureScheme: scheme,
+						Profile:         profile,
+					}
+					fields, err := webhookHeaders(cfg, payload, body, time.Now())
+					if err != nil {
+						t.Fatal(err)
+					}
+					header := make(http.Header)
+					for _, f := range fields {
+						header.Set(f.Name, f.Value)
+					}
+
+					l := &listener{opts: ListenOptions{
+						Secret:          tt.secret,
+						NextSecret:      tt.nextSecret,
+						SignatureScheme: scheme,
+						Profile:         profile,
+						Tolerance:       5 * time.Minute,
+					}}
+					err = l.verify([]byte(body), header)
+					if (err != nil) != tt.wantErr {
+						t.Errorf("verify() error = %v, want error %v", err, tt.wantErr)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSSELineSize caps a single line of the event stream.
+const maxSSELineSize = 1024 * 1024
+
+// sseEvent is a single event dispatched from a text/event-stream.
+type sseEvent struct {
+	Type string // Event type; "message" when the server sent no event field.
+	Data string // Data lines joined with "\n".
+	ID   string // Last event ID at the time the event was dispatched.
+}
+
+// sseReader parses a text/event-stream as specified by the HTML Living
+// Standard (section 9.2, "Server-sent events"). Data lines are accumulated
+// until a blank line dispatches the event, and the last event ID and
+// reconnection time are tracked across events.
+type sseReader struct {
+	scanner *bufio.Scanner
+
+	lastEventID string
+	retry       time.Duration // Zero until the server sends a retry field.
+
+	eventType string
+	data      strings.Builder
+	firstLine bool
+	skipLF    bool // The previous line ended in CR; ignore a following LF.
+}
+
+// newSSEReader returns an sseReader that parses events from r.
+func newSSEReader(r io.Reader) *sseReader {
+	p := &sseReader{firstLine: true}
+	p.scanner = bufio.NewScanner(r)
+	p.scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineSize)
+	p.scanner.Split(p.splitLines)
+	return p
+}
+
+// Next returns the next dispatched event. It returns io.EOF when the stream
+// ends; an event that was not terminated by a blank line is discarded.
+func (p *sseReader) Next() (sseEvent, error) {
+	for p.scanner.Scan() {
+		line := p.scanner.Text()
+		if p.firstLine {
+			line = strings.TrimPrefix(line, "\uFEFF")
+			p.firstLine = false
+		}
+
+		if line == "" {
+			if ev, ok := p.dispatch(); ok {
+				return ev, nil
+			}
+			continue
+		}
+
+		// Lines starting with ":" are comments.
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, found := strings.Cut(line, ":")
+		if found {
+			value = strings.TrimPrefix(value, " ")
+		}
+		p.processField(field, value)
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return sseEvent{}, err
+	}
+	return sseEvent{}, io.EOF
+}
+
+// LastEventID returns the most recent event ID sent by the server.
+func (p *sseReader) LastEventID() string {
+	return p.lastEventID
+}
+
+// Retry returns the reconnection time most recently sent by the server, or
+// zero if none was sent.
+func (p *sseReader) Retry() time.Duration {
+	return p.retry
+}
+
+// processField applies a single field of the event being built.
+func (p *sseReader) processField(field, value string) {
+	switch field {
+	case "event":
+		p.eventType = value
+	case "data":
+		p.data.WriteString(value)
+		p.data.WriteByte('\n')
+	case "id":
+		// IDs containing NULL are ignored per the spec.
+		if !strings.ContainsRune(value, 0) {
+			p.lastEventID = value
+		}
+	case "retry":
+		// ParseUint accepts only ASCII digits, as the spec requires.
+		if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+			p.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// dispatch finishes the current event and resets the buffers. It reports
+// false if there is nothing to dispatch because no data was received.
+func (p *sseReader) dispatch() (sseEvent, bool) {
+	data := p.data.String()
+	eventType := p.eventType
+	p.data.Reset()
+	p.eventType = ""
+
+	if data == "" {
+		return sseEvent{}, false
+	}
+	if eventType == "" {
+		eventType = "message"
+	}
+
+	return sseEvent{
+		Type: eventType,
+		Data: strings.TrimSuffix(data, "\n"),
+		ID:   p.lastEventID,
+	}, true
+}
+
+// splitLines is a bufio.SplitFunc that splits on CRLF, LF, or a lone CR, as
+// allowed by the event stream format. A CR is treated as a line end
+// immediately so CR-only streams are not delayed waiting for the next byte.
+func (p *sseReader) splitLines(data []byte, atEOF bool) (int, []byte, error) {
+	// State only changes when data is consumed, because the scanner may call
+	// the split function again with the same data after reading more.
+	start := 0
+	if p.skipLF && len(data) > 0 && data[0] == '\n' {
+		start = 1
+	}
+
+	if i := bytes.IndexAny(data[start:], "\r\n"); i >= 0 {
+		end := start + i
+		p.skipLF = data[end] == '\r'
+		return end + 1, data[start:end], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		p.skipLF = false
+		if len(data) == start {
+			return start, nil, nil
+		}
+		return len(data), data[start:], nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestSSEReaderNext(t *testing.T) {
+	tests := []struct {
+		name      string
+		stream    string
+		want      []sseEvent
+		wantID    string
+		wantRetry time.Duration
+	}{
+		{
+			name:   "empty",
+			stream: "",
+		},
+		{
+			name:   "single event",
+			stream: "data: hello\n\n",
+			want:   []sseEvent{{Type: "message", Data: "hello"}},
+		},
+		{
+			name:   "event type and id",
+			stream: "event: meta\nid: 7\ndata: {}\n\n",
+			want:   []sseEvent{{Type: "meta", Data: "{}", ID: "7"}},
+			wantID: "7",
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: first\ndata: second\ndata:\ndata: fourth\n\n",
+			want:   []sseEvent{{Type: "message", Data: "first\nsecond\n\nfourth"}},
+		},
+		{
+			name:   "only one leading space is stripped",
+			stream: "data:no space\n\ndata:  two spaces\n\n",
+			want: []sseEvent{
+				{Type: "message", Data: "no space"},
+				{Type: "message", Data: " two spaces"},
+			},
+		},
+		{
+			name:   "CRLF line endings",
+			stream: "id: 1\r\ndata: a\r\ndata: b\r\n\r\ndata: c\r\n\r\n",
+			want: []sseEvent{
+				{Type: "message", Data: "a\nb", ID: "1"},
+				{Type: "message", Data: "c", ID: "1"},
+			},
+			wantID: "1",
+		},
+		{
+			name:   "CR line endings",
+			stream: "data: a\rdata: b\r\rdata: c\r\r",
+			want: []sseEvent{
+				{Type: "message", Data: "a\nb"},
+				{Type: "message", Data: "c"},
+			},
+		},
+		{
+			name:   "mixed line endings",
+			stream: "data: a\r\ndata: b\rdata: c\n\r\n",
+			want:   []sseEvent{{Type: "message", Data: "a\nb\nc"}},
+		},
+		{
+			name:   "leading BOM",
+			stream: "\uFEFFdata: a\n\n",
+			want:   []sseEvent{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "BOM only stripped at the start",
+			stream: "data: a\n\n\uFEFFdata: b\n\n",
+			want:   []sseEvent{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "comments and unknown fields are ignored",
+			stream: ": keep-alive\nfoo: bar\ndata: a\n: more\n\n",
+			want:   []sseEvent{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "field without colon",
+			stream: "data\ndata\n\n",
+			want:   []sseEvent{{Type: "message", Data: "\n"}},
+		},
+		{
+			name:   "events without data are not dispatched",
+			stream: "event: ping\n\nid: 3\n\ndata: a\n\n",
+			want:   []sseEvent{{Type: "message", Data: "a", ID: "3"}},
+			wantID: "3",
+		},
+		{
+			name:   "id persists and can be cleared",
+			stream: "id: 5\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want: []sseEvent{
+				{Type: "message", Data: "a", ID: "5"},
+				{Type: "message", Data: "b", ID: "5"},
+				{Type: "message", Data: "c"},
+			},
+		},
+		{
+			name:   "id containing NULL is ignored",
+			stream: "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			want: []sseEvent{
+				{Type: "message", Data: "a", ID: "1"},
+				{Type: "message", Data: "b", ID: "1"},
+			},
+			wantID: "1",
+		},
+		{
+			name:      "retry",
+			stream:    "retry: 2500\ndata: a\n\n",
+			want:      []sseEvent{{Type: "message", Data: "a"}},
+			wantRetry: 2500 * time.Millisecond,
+		},
+		{
+			name:      "invalid retry is ignored",
+			stream:    "retry: 1000\n\nretry: 1.5\n\nretry: -1\n\nretry: soon\n\n",
+			wantRetry: time.Second,
+		},
+		{
+			name:   "unterminated event is discarded",
+			stream: "data: a\n\ndata: b\n",
+			want:   []sseEvent{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "event type resets after dispatch",
+			stream: "event: error\ndata: a\n\ndata: b\n\n",
+			want: []sseEvent{
+				{Type: "error", Data: "a"},
+				{Type: "message", Data: "b"},
+			},
+		},
+	}
+
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"whole", func(r io.Reader) io.Reader { return r }},
+		// One byte at a time splits every CRLF across reads.
+		{"bytewise", iotest.OneByteReader},
+	}
+
+	for _, tt := range tests {
+		for _, rd := range readers {
+			t.Run(tt.name+"/"+rd.name, func(t *testing.T) {
+				p := newSSEReader(rd.wrap(strings.NewReader(tt.stream)))
+				var got []sseEvent
+				for {
+					ev, err := p.Next()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("Next() error = %v", err)
+					}
+					got = append(got, ev)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("events = %q, want %q", got, tt.want)
+				}
+				if p.LastEventID() != tt.wantID {
+					t.Errorf("LastEventID() = %q, want %q", p.LastEventID(), tt.wantID)
+				}
+				if p.Retry() != tt.wantRetry {
+					t.Errorf("Retry() = %v, want %v", p.Retry(), tt.wantRetry)
+				}
+			})
+		}
+	}
+}
+
+func TestSSEReaderLineTooLong(t *testing.T) {
+	stream := "data: " + strings.Repeat("x", maxSSELineSize) + "\n\n"
+	_, err := newSSEReader(strings.NewReader(stream)).Next()
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("Next() error = %v, want a line length error", err)
+	}
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"math"
+	"sort"
+)
+
+// LatencyStats summarizes a set of delivery latencies in milliseconds.
+// Percentiles use the nearest-rank method.
+type LatencyStats struct {
+	Count int   `json:"count"`
+	MinMs int64 `json:"min_ms"`
+	AvgMs int64 `json:"avg_ms"`
+	P50Ms int64 `json:"p50_ms"`
+	P90Ms int64 `json:"p90_ms"`
+	P95Ms int64 `json:"p95_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+// computeLatencyStats returns latency statistics for the given results. It
+// returns the zero value if results is empty.
+func computeLatencyStats(results []DeliveryResult) LatencyStats {
+	if len(results) == 0 {
+		return LatencyStats{}
+	}
+
+	latencies := make([]int64, len(results))
+	var total int64
+	for i, r := range results {
+		latencies[i] = r.LatencyMs
+		total += r.LatencyMs
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	return LatencyStats{
+		Count: len(latencies),
+		MinMs: latencies[0],
+		AvgMs: total / int64(len(latencies)),
+		P50Ms: percentile(latencies, 50),
+		P90Ms: percentile(latencies, 90),
+		P95Ms: percentile(latencies, 95),
+		P99Ms: percentile(latencies, 99),
+		MaxMs: latencies[len(latencies)-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted using the nearest-rank
+// method. sorted must be non-empty and in ascending order.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+// HistogramBucket counts deliveries whose latency falls at or below UpperMs
+// and above the previous bucket's bound. The last bucket has UpperMs 0 and
+// collects everything slower than the largest bound.
+type HistogramBucket struct {
+	UpperMs int64 `json:"upper_ms"`
+	Count   int   `json:"count"`
+}
+
+// histogramBounds are the upper bounds, in milliseconds, of the latency
+// histogram buckets. They roughly follow a 1-2.5-5 progression so that both
+// fast local handlers and slow remote ones get useful resolution.
+var histogramBounds = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// computeHistogram buckets the latencies of results. The result always has
+// len(histogramBounds)+1 buckets.
+func computeHistogram(results []DeliveryResult) []HistogramBucket {
+	buckets := make([]HistogramBucket, len(histogramBounds)+1)
+	for i, bound := range histogramBounds {
+		buckets[i].UpperMs = bound
+	}
+
+	for _, r := range results {
+		i := sort.Search(len(histogramBounds), func(i int) bool { return r.LatencyMs <= histogramBounds[i] })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// splitBySuccess partitions results into successful and failed deliveries.
+func splitBySuccess(results []DeliveryResult) (succeeded, failed []DeliveryResult) {
+	for _, r := range results {
+		if r.Success {
+			succeeded = append(succeeded, r)
+		} else {
+			failed = append(failed, r)
+		}
+	}
+	return succeeded, failed
+}
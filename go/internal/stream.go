@@ -1,12 +1,14 @@
 package internal
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 )
 
 // StreamCallbacks defines the callback functions invoked for each SSE event type.
@@ -15,24 +17,205 @@ type StreamCallbacks struct {
 	OnPayload  func(payload WebhookPayload)
 	OnComplete func(message string)
 	OnError    func(message string)
+
+	// OnEventID is called before an event is dispatched whenever the last
+	// event ID set by the server's "id:" field changes.
+	OnEventID func(id string)
+	// OnRetry is called when the server sets a new reconnection time with
+	// a "retry:" field.
+	OnRetry func(retry time.Duration)
+	// OnReconnect is called before each reconnection attempt with the
+	// 1-based attempt number, the delay before it, and the error that ended
+	// the previous connection (nil if the server closed it cleanly).
+	OnReconnect func(attempt int, delay time.Duration, cause error)
+}
+
+// ReconnectPolicy controls automatic reconnection when the stream drops
+// before the server sends a complete or error event.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive reconnection attempts before
+	// giving up. Zero disables reconnection.
+	MaxAttempts int
+	// BaseDelay is the initial reconnection delay, used until the server
+	// sends a "retry:" field. Delays grow exponentially up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Deadline stops reconnection once the session has expired. If zero, it
+	// is derived from the stream duration in the first meta event; without
+	// either, only MaxAttempts limits reconnection.
+	Deadline time.Time
+}
+
+// StreamStats summarizes the health of a stream across reconnections.
+type StreamStats struct {
+	Reconnects  int    // Reconnection attempts made.
+	LastEventID string // Last event ID received from the server.
+	Gaps        int    // Events missing from the sequence of numeric event IDs.
+	Duplicates  int    // Payloads received more than once and not dispatched.
+	Expired     bool   // Reconnection stopped because the session expired.
+}
+
+// streamStatusError reports a non-200 response from the stream endpoint.
+type streamStatusError struct {
+	Status int
+}
+
+func (e *streamStatusError) Error() string {
+	return fmt.Sprintf("stream returned status %d", e.Status)
+}
+
+// permanent reports whether reconnecting cannot fix the error, such as an
+// invalid secret or an expired session.
+func (e *streamStatusError) permanent() bool {
+	return e.Status >= 400 && e.Status < 500 &&
+		e.Status != http.StatusRequestTimeout && e.Status != http.StatusTooManyRequests
+}
+
+// streamConn holds the state of a stream across reconnections.
+type streamConn struct {
+	url       string
+	secret    string
+	policy    ReconnectPolicy
+	transport http.RoundTripper
+	callbacks StreamCallbacks
+
+	stats       StreamStats
+	retry       time.Duration // Server-sent reconnection time.
+	deadline    time.Time
+	connected   bool // At least one connection succeeded.
+	received    bool // The current connection received at least one event.
+	finished    bool // The server sent a complete or error event.
+	lastSeq     int64
+	hasSeq      bool
+	seenEventID *recentIDs
+}
+
+// seenEventIDWindow is how many recent payload event IDs are remembered to
+// skip payloads the server sends again after a resume.
+const seenEventIDWindow = 10000
+
+// recentIDs is a set holding only the most recently added IDs, so that
+// memory stays bounded on long streams.
+type recentIDs struct {
+	set  map[string]struct{}
+	ring []string // Added IDs, oldest at next once the ring is full.
+	next int
+}
+
+func newRecentIDs(size int) *recentIDs {
+	return &recentIDs{set: make(map[string]struct{}, size), ring: make([]string, 0, size)}
+}
+
+// add records id and reports whether it was not already present. Once the
+// window is full, the oldest ID is forgotten.
+func (r *recentIDs) add(id string) bool {
+	if _, ok := r.set[id]; ok {
+		return false
+	}
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, id)
+	} else {
+		delete(r.set, r.ring[r.next])
+		r.ring[r.next] = id
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.set[id] = struct{}{}
+	return true
 }
 
 // ConnectStream connects to the SSE stream at streamURL and processes events
-// via the provided callbacks. It blocks until the stream ends, the context is
-// cancelled, or an error occurs.
-func ConnectStream(ctx context.Context, streamURL, secret string, callbacks StreamCallbacks) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+// via the provided callbacks. It blocks until the server completes the
+// stream, the context is cancelled, or an unrecoverable error occurs.
+//
+// If the connection drops after it was established, ConnectStream reconnects
+// according to policy, sending Last-Event-ID so the server can resume.
+// Payloads already received are not dispatched again. The returned
+// StreamStats describe reconnections and any gaps or duplicates detected.
+// Connections are made through transport, or http.DefaultTransport if it is
+// nil.
+func ConnectStream(ctx context.Context, streamURL, secret string, policy ReconnectPolicy, transport http.RoundTripper, callbacks StreamCallbacks) (StreamStats, error) {
+	s := &streamConn{
+		url:         streamURL,
+		transport:   transport,
+		secret:      secret,
+		policy:      policy,
+		callbacks:   callbacks,
+		deadline:    policy.Deadline,
+		seenEventID: newRecentIDs(seenEventIDWindow),
+	}
+
+	attempt := 0
+	for {
+		s.received = false
+		err := s.connect(ctx)
+
+		if ctx.Err() != nil {
+			return s.stats, ctx.Err()
+		}
+		if s.finished || !s.connected {
+			return s.stats, err
+		}
+
+		var statusErr *streamStatusError
+		if errors.As(err, &statusErr) && statusErr.permanent() {
+			return s.stats, err
+		}
+
+		if s.received {
+			attempt = 0
+		}
+		attempt++
+
+		if policy.MaxAttempts <= 0 {
+			return s.stats, err
+		}
+		if attempt > policy.MaxAttempts {
+			if err == nil {
+				err = errors.New("stream closed by server")
+			}
+			return s.stats, fmt.Errorf("giving up after %d reconnect attempts: %w", policy.MaxAttempts, err)
+		}
+
+		base := policy.BaseDelay
+		if s.retry > 0 {
+			base = s.retry
+		}
+		delay := RetryPolicy{BaseDelay: base, MaxDelay: max(policy.MaxDelay, base)}.backoff(attempt, 0)
+
+		// The session has expired; there is nothing left to resume.
+		if !s.deadline.IsZero() && time.Now().Add(delay).After(s.deadline) {
+			s.stats.Expired = true
+			return s.stats, nil
+		}
+
+		if callbacks.OnReconnect != nil {
+			callbacks.OnReconnect(attempt, delay, err)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return s.stats, err
+		}
+		s.stats.Reconnects++
+	}
+}
+
+// connect opens a single connection and processes events until it ends. It
+// returns nil if the server closed the stream cleanly.
+func (s *streamConn) connect(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create stream request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+secret)
+	req.Header.Set("Authorization", "Bearer "+s.secret)
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
+	if s.stats.LastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.stats.LastEventID)
+	}
 
 	// No timeout on the SSE client -- the stream is long-lived.
-	client := &http.Client{}
+	client := &http.Client{Transport: s.transport}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -40,66 +223,105 @@ func ConnectStream(ctx context.Context, streamURL, secret string, callbacks Stre
 	}
 	defer resp.Body.Close() //nolint:errcheck // response body close error is non-actionable
 
+	// 204 No Content tells the client to stop reconnecting.
+	if resp.StatusCode == http.StatusNoContent {
+		s.finished = true
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("stream returned status %d", resp.StatusCode)
+		return &streamStatusError{Status: resp.StatusCode}
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	// Allow up to 1 MB per SSE line to handle large payloads.
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	s.connected = true
+
+	// The last event ID carries over from the previous connection.
+	reader := newSSEReader(resp.Body)
+	reader.lastEventID = s.stats.LastEventID
 
-	var currentEvent string
+	for {
+		ev, err := reader.Next()
 
-	for scanner.Scan() {
-		// Check for context cancellation between lines.
+		// Check for context cancellation between events.
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
 
-		line := scanner.Text()
-
-		// SSE spec: lines starting with ":" are comments, skip them.
-		if strings.HasPrefix(line, ":") {
-			continue
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream read error: %w", err)
 		}
 
-		// Empty line = end of event block.
-		if line == "" {
-			currentEvent = ""
-			continue
+		s.received = true
+
+		if r := reader.Retry(); r > 0 && r != s.retry {
+			s.retry = r
+			if s.callbacks.OnRetry != nil {
+				s.callbacks.OnRetry(r)
+			}
 		}
 
-		if strings.HasPrefix(line, "event:") {
-			currentEvent = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-			continue
+		if ev.ID != s.stats.LastEventID {
+			s.stats.LastEventID = ev.ID
+			if s.callbacks.OnEventID != nil {
+				s.callbacks.OnEventID(ev.ID)
+			}
 		}
 
-		if strings.HasPrefix(line, "data:") {
-			data := strings.TrimPrefix(line, "data:")
-			data = strings.TrimSpace(data)
+		s.handle(ev)
+	}
+}
 
-			dispatchEvent(currentEvent, data, callbacks)
-			continue
+// handle tracks stream bookkeeping for an event and dispatches it, unless it
+// is a payload that was already received on an earlier connection.
+func (s *streamConn) handle(ev sseEvent) {
+	switch ev.Type {
+	case "meta":
+		var meta StreamMeta
+		if err := json.Unmarshal([]byte(ev.Data), &meta); err == nil && meta.StreamDurationSeconds > 0 && s.deadline.IsZero() {
+			s.deadline = time.Now().Add(time.Duration(meta.StreamDurationSeconds) * time.Second)
+		}
+	case "complete", "error":
+		s.finished = true
+	default:
+		if s.isDuplicate(ev) {
+			s.stats.Duplicates++
+			return
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		// If the context was cancelled, treat it as a clean shutdown.
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	dispatchEvent(ev.Type, ev.Data, s.callbacks)
+}
+
+// isDuplicate reports whether a payload with the same event_id was among
+// the last seenEventIDWindow received, for example because the server
+// replayed it after a resume. It
+// also counts gaps in numeric SSE event IDs; a lower ID than the previous one
+// is treated as the server restarting its sequence.
+func (s *streamConn) isDuplicate(ev sseEvent) bool {
+	if seq, err := strconv.ParseInt(ev.ID, 10, 64); err == nil {
+		if s.hasSeq && seq > s.lastSeq+1 {
+			s.stats.Gaps += int(seq - s.lastSeq - 1)
 		}
-		return fmt.Errorf("stream read error: %w", err)
+		s.lastSeq = seq
+		s.hasSeq = true
 	}
 
-	return nil
+	var payload struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal([]byte(ev.Data), &payload); err != nil || payload.EventID == "" {
+		return false
+	}
+	return !s.seenEventID.add(payload.EventID)
 }
 
 // dispatchEvent routes a parsed SSE data payload to the appropriate callback
-// based on the event type.
+// based on the event type. Any type other than meta, complete, or error
+// (including the default "message" type) is treated as a webhook payload.
 func dispatchEvent(eventType, data string, callbacks StreamCallbacks) {
 	switch eventType {
 	case "meta":
@@ -121,7 +343,6 @@ func dispatchEvent(eventType, data string, callbacks StreamCallbacks) {
 		}
 
 	default:
-		// Default: treat as webhook payload.
 		if callbacks.OnPayload != nil {
 			var payload WebhookPayload
 			if err := json.Unmarshal([]byte(data), &payload); err == nil {
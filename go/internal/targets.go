@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Target is a single delivery destination. Secret and Headers, when set,
+// apply to this target only.
+type Target struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret,omitempty"`  // Overrides the run's signing secret.
+	Headers map[string]string `json:"headers,omitempty"` // Extra request headers; values may reference $ENV_VARS.
+}
+
+// LoadTargets reads a JSON array of targets, as passed to -targets, and
+// expands environment variables in header values.
+func LoadTargets(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets file %s: %w", path, err)
+	}
+
+	var targets []Target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("invalid targets file %s: %w", path, err)
+	}
+	for i, t := range targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("invalid targets file %s: target %d has no url", path, i+1)
+		}
+		for name, value := range t.Headers {
+			expanded, err := ExpandEnv(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid targets file %s: header %s: %w", path, name, err)
+			}
+			t.Headers[name] = expanded
+		}
+	}
+	return targets, nil
+}
+
+// ResolveTargets combines the targets given by -url with those loaded from a
+// targets file and gives every target a unique name. Unnamed targets are
+// named after their URL's host, or their socket path for unix targets.
+func ResolveTargets(urls []string, fileTargets []Target) []Target {
+	targets := make([]Target, 0, len(urls)+len(fileTargets))
+	for _, u := range urls {
+		targets = append(targets, Target{URL: u})
+	}
+	targets = append(targets, fileTargets...)
+
+	used := make(map[string]bool)
+	for i := range targets {
+		name := targets[i].Name
+		if name == "" {
+			name = defaultTargetName(targets[i].URL)
+		}
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = name + "#" + strconv.Itoa(n)
+		}
+		used[unique] = true
+		targets[i].Name = unique
+	}
+	return targets
+}
+
+// defaultTargetName returns the name of an unnamed target.
+func defaultTargetName(target string) string {
+	if rest, ok := strings.CutPrefix(target, "unix://"); ok {
+		socket, _, _ := strings.Cut(rest, ":")
+		if socket != "" {
+			return socket
+		}
+	}
+	if parsed, err := url.Parse(target); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return target
+}
+
+// targetURLs returns the URLs of targets joined for display.
+func targetURLs(targets []Target) string {
+	urls := make([]string, len(targets))
+	for i, t := range targets {
+		urls[i] = t.URL
+	}
+	return strings.Join(urls, " + ")
+}
+
+// deliveryConfigs returns one DeliveryConfig per target, signing with secret
+// unless a target sets its own, and sending the headers from both o and the
+// target. HTTP(S) targets share one transport, and so its idle connections,
+// configured by o.TLS, o.Proxy, and o.Client; other schemes get a transport
+// of their own from targetTransport. Results are labelled with the target
+// name only when there is more than one target.
+func (o DeliveryOptions) deliveryConfigs(targets []Target, secret string) ([]DeliveryConfig, error) {
+	base, err := newDeliveryTransport(o)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs := make([]DeliveryConfig, len(targets))
+	for i, t := range targets {
+		requestURL, transport, err := targetTransport(t.URL, base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %s: %w", t.URL, err)
+		}
+
+		cfg := DeliveryConfig{
+			URL:             requestURL,
+			Secret:          secret,
+			NextSecret:      o.NextSecret,
+			Rotation:        o.Rotation,
+			SignatureScheme: o.SignatureScheme,
+			Profile:         o.Profile,
+			Retry:           o.retryPolicy(),
+			Expect:          o.Expect,
+			Client:          &http.Client{Timeout: o.Client.Timeout, Transport: transport},
+			Transform:       o.Transform,
+			CloudEvents:     o.CloudEvents,
+		}
+		if t.Secret != "" {
+			cfg.Secret = t.Secret
+		}
+		if len(targets) > 1 {
+			cfg.Target = t.Name
+		}
+
+		// Target headers come last so they take precedence.
+		cfg.Headers = o.requestHeaders()
+		names := make([]string, 0, len(t.Headers))
+		for name := range t.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cfg.Headers = append(cfg.Headers, headerField{name, t.Headers[name]})
+		}
+
+		cfgs[i] = cfg
+	}
+	return cfgs, nil
+}
+
+// TargetSummary holds the delivery statistics of a single target.
+type TargetSummary struct {
+	Name    string        `json:"name"`
+	URL     string        `json:"url"`
+	Summary ReportSummary `json:"summary"`
+}
+
+// summarizeTargets groups results by target. It returns nil for runs with a
+// single target.
+func summarizeTargets(results []DeliveryResult, targets []Target) []TargetSummary {
+	if len(targets) < 2 {
+		return nil
+	}
+
+	byTarget := make(map[string][]DeliveryResult)
+	for _, r := range results {
+		byTarget[r.Target] = append(byTarget[r.Target], r)
+	}
+
+	summaries := make([]TargetSummary, len(targets))
+	for i, t := range targets {
+		summaries[i] = TargetSummary{
+			Name:    t.Name,
+			URL:     t.URL,
+			Summary: buildSummary(byTarget[t.Name]),
+		}
+	}
+	return summaries
+}
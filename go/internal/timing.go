@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// DeliveryTiming breaks down where the time of a delivery attempt went.
+// Phases that did not happen, such as DNS and connecting on a reused
+// connection, are zero.
+type DeliveryTiming struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration // From the request being fully written to the first response byte.
+	Reused  bool          // The request went over an idle keep-alive connection.
+}
+
+// timingRecorder collects a DeliveryTiming from httptrace hooks, which the
+// transport may call from several goroutines.
+type timingRecorder struct {
+	mu     sync.Mutex
+	timing DeliveryTiming
+
+	dnsStart, connectStart, tlsStart, wroteRequest time.Time
+}
+
+// trace returns the httptrace hooks that feed r.
+func (r *timingRecorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.record(func() { r.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.record(func() { r.timing.DNS = time.Since(r.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			r.record(func() {
+				if r.connectStart.IsZero() {
+					r.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			r.record(func() {
+				if err == nil {
+					r.timing.Connect = time.Since(r.connectStart)
+				}
+			})
+		},
+		TLSHandshakeStart: func() {
+			r.record(func() { r.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.record(func() { r.timing.TLS = time.Since(r.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.record(func() { r.timing.Reused = info.Reused })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			r.record(func() { r.wroteRequest = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			r.record(func() {
+				// A response that arrives before the request is fully written,
+				// such as an early rejection, has no meaningful TTFB.
+				if !r.wroteRequest.IsZero() {
+					r.timing.TTFB = time.Since(r.wroteRequest)
+				}
+			})
+		},
+	}
+}
+
+func (r *timingRecorder) record(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f()
+}
+
+// result returns the timing recorded so far.
+func (r *timingRecorder) result() DeliveryTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.timing
+}
+
+// durationMs converts d to fractional milliseconds, rounded to microseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tlsVersions maps the values accepted by -tls-min-version to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions configures TLS for deliveries to HTTPS targets. The zero value
+// uses Go's default TLS settings.
+type TLSOptions struct {
+	CAFile     string // PEM bundle of additional CAs trusted for the target.
+	CertFile   string // PEM client certificate for mutual TLS.
+	KeyFile    string // PEM private key for CertFile.
+	ServerName string // Overrides the SNI name and the name the certificate is verified against.
+	MinVersion string // Minimum TLS version: "1.0", "1.1", "1.2", or "1.3".
+	Insecure   bool   // Skip certificate verification.
+}
+
+// ValidateTLSVersion checks the value of a -tls-min-version flag.
+func ValidateTLSVersion(version string) error {
+	if _, ok := tlsVersions[version]; version != "" && !ok {
+		return fmt.Errorf("unknown TLS version %q (want 1.0, 1.1, 1.2, or 1.3)", version)
+	}
+	return nil
+}
+
+// config builds the tls.Config described by o, or returns nil if o is the
+// zero value.
+func (o TLSOptions) config() (*tls.Config, error) {
+	if o == (TLSOptions{}) {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.Insecure, //nolint:gosec // explicitly requested with -insecure
+	}
+
+	if o.MinVersion != "" {
+		version, ok := tlsVersions[o.MinVersion]
+		if !ok {
+			return nil, ValidateTLSVersion(o.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", o.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("a client certificate and key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// negotiatedTLS returns the TLS version and cipher suite names of a
+// connection, e.g. "TLS 1.3" and "TLS_AES_128_GCM_SHA256", or empty strings
+// for plain HTTP.
+func negotiatedTLS(state *tls.ConnectionState) (version, cipher string) {
+	if state == nil {
+		return "", ""
+	}
+	return tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite)
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PayloadTransform reshapes a WebhookPayload into a different JSON body
+// before it is signed and delivered, so consumers that expect another
+// envelope can be tested with CertWatch data. It is a Go text/template
+// executed with the payload as its data.
+type PayloadTransform struct {
+	Path string // Template file, shown in output.
+	tmpl *template.Template
+}
+
+// transformFuncs are the functions available to transform templates in
+// addition to the text/template builtins.
+var transformFuncs = template.FuncMap{
+	// json encodes a value as JSON, e.g. {{json .Data.Domains}}. Use it for
+	// every string so that quotes and backslashes are escaped.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"now":   func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"uuid":  generateUUIDv4,
+}
+
+// LoadTransform parses the template in path and checks that it renders
+// valid JSON for a sample payload, so mistakes such as misspelled fields
+// are reported before any delivery.
+func LoadTransform(path string) (*PayloadTransform, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transform: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(transformFuncs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transform: %w", err)
+	}
+
+	t := &PayloadTransform{Path: path, tmpl: tmpl}
+	if _, err := t.Render(GenerateSamplePayload()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render executes the template for payload and returns the resulting body
+// with surrounding whitespace trimmed. It is an error for the template to
+// produce anything other than a single JSON value.
+func (t *PayloadTransform) Render(payload WebhookPayload) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to transform payload: %w", err)
+	}
+	body := bytes.TrimSpace(buf.Bytes())
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("transform %s did not produce valid JSON", t.Path)
+	}
+	return body, nil
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// transportFunc builds the transport for a target whose URL uses a scheme
+// other than http or https. It returns the URL to send requests to and a
+// transport derived from base, so TLS and connection settings carry over.
+type transportFunc func(target string, base *http.Transport) (string, *http.Transport, error)
+
+// targetTransports maps custom target URL schemes to their transports.
+var targetTransports = map[string]transportFunc{
+	"unix": unixTransport,
+	"h2c":  h2cTransport,
+}
+
+// newDeliveryTransport returns the base transport used to deliver to
+// targets, with TLS, the proxy, and connection handling configured according
+// to o.
+func newDeliveryTransport(o DeliveryOptions) (*http.Transport, error) {
+	tlsConfig, err := o.TLS.config()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := o.Proxy.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: o.Client.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = proxy
+	transport.TLSHandshakeTimeout = o.Client.TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = o.Client.ResponseHeaderTimeout
+	transport.DisableKeepAlives = o.Client.DisableKeepAlives
+	if o.Client.MaxIdleConns > 0 {
+		transport.MaxIdleConns = o.Client.MaxIdleConns
+		transport.MaxIdleConnsPerHost = o.Client.MaxIdleConns
+	}
+	return transport, nil
+}
+
+// targetTransport returns the request URL and transport for target. http and
+// https targets use base as-is.
+func targetTransport(target string, base *http.Transport) (string, *http.Transport, error) {
+	scheme, _, ok := strings.Cut(target, "://")
+	if !ok {
+		return target, base, nil
+	}
+	build, ok := targetTransports[strings.ToLower(scheme)]
+	if !ok {
+		return target, base, nil
+	}
+	return build(target, base)
+}
+
+// unixTransport handles "unix:///path/to.sock:/webhook" targets, which send
+// requests for /webhook over the Unix domain socket /path/to.sock. The
+// request path defaults to "/".
+func unixTransport(target string, base *http.Transport) (string, *http.Transport, error) {
+	rest := target[len("unix://"):]
+	socket, path, _ := strings.Cut(rest, ":")
+	if socket == "" {
+		return "", nil, errors.New("unix target has no socket path (want unix:///path/to.sock:/webhook)")
+	}
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", nil, fmt.Errorf("invalid request path %q in unix target (want unix:///path/to.sock:/webhook)", path)
+	}
+
+	dial := base.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport := base.Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, "unix", socket)
+	}
+	return "http://localhost" + path, transport, nil
+}
@@ -0,0 +1,16 @@
+//go:build go1.24
+
+package internal
+
+import (
+	"net/http"
+)
+
+// h2cTransport handles "h2c://host:port/path" targets, which are sent over
+// HTTP/2 cleartext with prior knowledge.
+func h2cTransport(target string, base *http.Transport) (string, *http.Transport, error) {
+	transport := base.Clone()
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	return "http://" + target[len("h2c://"):], transport, nil
+}
@@ -0,0 +1,14 @@
+//go:build !go1.24
+
+package internal
+
+import (
+	"errors"
+	"net/http"
+)
+
+// h2cTransport rejects h2c targets: HTTP/2 cleartext needs the
+// http.Protocols support added to net/http in Go 1.24.
+func h2cTransport(string, *http.Transport) (string, *http.Transport, error) {
+	return "", nil, errors.New("h2c targets require a build with Go 1.24 or later")
+}
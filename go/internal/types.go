@@ -1,8 +1,13 @@
 package internal
 
+import (
+	"net/http"
+	"time"
+)
+
 // CliOptions holds the parsed command-line flags for the webhook CLI.
 type CliOptions struct {
-	URL         string
+	Targets     []Target // Delivery targets; empty when not delivering.
 	Secret      string
 	APIKey      string
 	File        string // Path to JSONL output file.
@@ -11,8 +16,179 @@ type CliOptions struct {
 	Verbose     bool
 	NoColor     bool
 	APIEndpoint string
+	Report      string // Path to write a JSON run report.
+	JUnit       string // Path to write a JUnit XML report.
+
+	// Filter selects the payloads that are written and delivered.
+	Filter PayloadFilter
+
+	// Stream reconnection settings.
+	MaxReconnects  int           // Consecutive reconnect attempts; 0 disables reconnection.
+	ReconnectDelay time.Duration // Initial delay until the server sends "retry:".
+
+	// APIProxy applies to the CertWatch API and stream connections.
+	APIProxy ProxyOptions
+
+	DeliveryOptions
+}
+
+// DeliveryOptions holds the command-line settings that control how payloads
+// are delivered to the target URL. They are shared by every command that
+// delivers payloads.
+type DeliveryOptions struct {
+	// Retry settings.
+	MaxAttempts   int           // Total attempts per payload; 1 disables retries.
+	RetryDelay    time.Duration // Base delay for exponential backoff.
+	RetryMaxDelay time.Duration // Upper bound for a single backoff delay.
+
+	// Worker pool settings.
+	Concurrency int  // Number of concurrent delivery workers.
+	QueueSize   int  // Payloads buffered between the source and the workers.
+	Unordered   bool // Report results as they complete instead of in source order.
+
+	// SignatureScheme is one of SignatureSchemeBody or
+	// SignatureSchemeTimestamped.
+	SignatureScheme string
+
+	// Profile selects which webhook headers are sent: one of
+	// HeaderProfileCertWatch, HeaderProfileStandardWebhooks, or
+	// HeaderProfileBoth.
+	Profile string
+
+	// Secret rotation settings. NextSecret is the secret being rotated in;
+	// Rotation selects which secrets sign each delivery.
+	NextSecret string
+	Rotation   string // One of RotationOld, RotationNew, or RotationBoth.
+
+	Chaos ChaosOptions
+
+	// Expect declares what counts as a successful response.
+	Expect Expectations
+
+	// Extra request headers sent with every delivery, e.g. for an auth
+	// gateway in front of the endpoint. BasicAuth ("user:password") or
+	// BearerToken adds an Authorization header.
+	Headers     http.Header
+	BasicAuth   string
+	BearerToken string
+
+	TLS TLSOptions
+
+	// Proxy applies to deliveries only; see CliOptions.APIProxy.
+	Proxy ProxyOptions
+
+	Client ClientOptions
+
+	// Transform reshapes each payload into a different JSON body before it
+	// is signed; nil delivers the payload unchanged.
+	Transform *PayloadTransform
+
+	// CloudEvents delivers payloads as CloudEvents instead of CertWatch
+	// webhooks.
+	CloudEvents CloudEventsOptions
+}
+
+// ClientOptions tunes the HTTP client shared by all deliveries. Zero
+// timeouts disable the corresponding limit.
+type ClientOptions struct {
+	Timeout               time.Duration // Overall limit for a delivery attempt, including reading the response.
+	ConnectTimeout        time.Duration // Limit for establishing a TCP or Unix socket connection.
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // Limit for receiving response headers after the request is written.
+	DisableKeepAlives     bool          // Open a new connection for every delivery.
+	MaxIdleConns          int           // Idle connections kept per host; zero uses Go's defaults.
+}
+
+// ChaosOptions configures fault injection. Each payload receives at most one
+// fault, chosen at random according to Faults.
+type ChaosOptions struct {
+	Faults       map[string]float64 // Probability per fault, keyed by Fault* name.
+	Seed         uint64             // Random seed; the CLI picks one at random unless -chaos-seed is set.
+	SlowDuration time.Duration      // Total time to write a slowloris body.
+}
+
+// ReplayOptions holds the parsed command-line flags for the replay command.
+type ReplayOptions struct {
+	File    string   // Path to a JSONL file written by -file.
+	Targets []Target // Delivery targets.
+	Secret  string
+	Timing  string        // One of ReplayTimingOriginal, ReplayTimingRate, or ReplayTimingFast.
+	Rate    float64       // Payloads per second for ReplayTimingRate.
+	Filter  PayloadFilter // Selects the payloads that are delivered.
+	Report  string        // Path to write a JSON run report.
+	JUnit   string        // Path to write a JUnit XML report.
+	Verbose bool
+	NoColor bool
+
+	DeliveryOptions
 }
 
+// ListenOptions holds the parsed command-line flags for the listen command.
+type ListenOptions struct {
+	Secret  string
+	Host    string
+	Port    int
+	Path    string // URL path that receives webhooks.
+	File    string // Append verified payloads to this JSONL file.
+	Count   int    // Exit after this many payloads; 0 runs until interrupted.
+	Verbose bool
+	NoColor bool
+
+	NextSecret      string        // Also accept signatures made with this secret.
+	SignatureScheme string        // Scheme the sender signs with.
+	Profile         string        // Which signature headers to verify.
+	Tolerance       time.Duration // Maximum age of timestamped signatures.
+}
+
+// MockServerOptions holds the parsed command-line flags for the mock-server
+// command.
+type MockServerOptions struct {
+	Host       string
+	Port       int
+	Fixture    string        // JSONL file of payloads to stream; empty generates samples.
+	Rate       float64       // Payloads per second.
+	Duration   time.Duration // Stream duration before the complete event.
+	Secret     string        // Required stream secret; empty accepts any secret.
+	ErrorAfter int           // Send an error event after this many payloads; 0 disables.
+	DropAfter  int           // Abort each connection after this many payloads; 0 disables.
+	Retry      time.Duration // Reconnection time sent in a "retry:" field; 0 omits it.
+	NoColor    bool
+}
+
+// Replay timing modes.
+const (
+	ReplayTimingOriginal = "original" // Preserve the original inter-arrival times.
+	ReplayTimingRate     = "rate"     // Deliver at a fixed rate.
+	ReplayTimingFast     = "fast"     // Deliver as fast as possible.
+)
+
+// Signature schemes for the X-CertWatch-Signature header.
+const (
+	// SignatureSchemeBody signs the request body only: "sha256=<hex>".
+	SignatureSchemeBody = "body"
+	// SignatureSchemeTimestamped binds the signature to the delivery time:
+	// "t=<unix>,v1=<hex>", where the HMAC covers "<unix>.<body>".
+	SignatureSchemeTimestamped = "timestamped"
+)
+
+// Header profiles select which webhook headers a delivery carries.
+const (
+	// HeaderProfileCertWatch sends the X-CertWatch-* headers.
+	HeaderProfileCertWatch = "certwatch"
+	// HeaderProfileStandardWebhooks sends the Standard Webhooks headers
+	// (webhook-id, webhook-timestamp, webhook-signature).
+	HeaderProfileStandardWebhooks = "standard-webhooks"
+	// HeaderProfileBoth sends both sets of headers.
+	HeaderProfileBoth = "both"
+)
+
+// Secret rotation modes select which secrets sign a delivery.
+const (
+	RotationOld  = "old"  // Sign with the current secret only.
+	RotationNew  = "new"  // Sign with the next secret only.
+	RotationBoth = "both" // Sign with both secrets.
+)
+
 // SessionResponse is the JSON envelope returned by the session creation API.
 type SessionResponse struct {
 	Success bool          `json:"success"`
@@ -64,14 +240,67 @@ type StreamMeta struct {
 	StreamDurationSeconds int    `json:"streamDurationSeconds"`
 }
 
+// RetryPolicy controls how failed deliveries are retried. Network errors and
+// non-2xx responses are retried with exponential backoff and jitter until
+// MaxAttempts is reached. A MaxAttempts of 1 or less disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DeliveryConfig holds the settings shared by every delivery in a run.
+type DeliveryConfig struct {
+	Target          string // Target name shown with results; empty for single-target runs.
+	URL             string
+	Secret          string
+	NextSecret      string
+	Rotation        string
+	SignatureScheme string
+	Profile         string
+	Retry           RetryPolicy
+	Expect          Expectations
+	Headers         []headerField     // Extra request headers.
+	Client          *http.Client      // Client used for deliveries; nil uses a default client.
+	Transform       *PayloadTransform // Reshapes the body before signing; nil sends the payload as is.
+	CloudEvents     CloudEventsOptions
+	KeepResponse    bool // Keep the response of successful deliveries too, e.g. to print them.
+}
+
 // DeliveryResult records the outcome of delivering a single webhook payload
 // to the user's local endpoint.
 type DeliveryResult struct {
+	Target     string // Target name; empty for single-target runs.
 	Index      int
+	EventID    string
 	CommonName string
 	Status     int
 	StatusText string
 	LatencyMs  int64
 	Success    bool
 	Error      string
+	Attempt    int           // 1-based attempt number that produced this result.
+	RetryIn    time.Duration // Backoff before the next attempt; zero if none is scheduled.
+	Fault      string        // Chaos fault injected into this delivery, if any.
+
+	// ExpectationFailures lists the unmet expectations of a response whose
+	// status was expected.
+	ExpectationFailures []string
+
+	// The endpoint's response, with the body capped at maxResponseBodySize.
+	// Only kept for failed deliveries unless DeliveryConfig.KeepResponse is
+	// set or a body expectation was checked.
+	ResponseHeaders       http.Header
+	ResponseBody          string
+	ResponseBodyTruncated bool
+
+	// The negotiated TLS version and cipher suite; empty for plain HTTP.
+	TLSVersion string
+	TLSCipher  string
+
+	Timing DeliveryTiming
+
+	retryAfter time.Duration // Server-requested delay from a Retry-After header.
+	statusMet  bool          // The response status was an expected one.
+	permanent  bool          // The request could not be built; retrying would fail the same way.
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/certwatch-app/certwatch-webhook-cli/go/internal"
+)
+
+// runListen implements the "listen" command, a webhook receiver that
+// verifies signatures on every delivery.
+func runListen(args []string) {
+	fs := flag.NewFlagSet("listen", flag.ExitOnError)
+
+	secret := fs.String("secret", "", "Webhook signing secret used to verify signatures")
+	nextSecret := fs.String("next-secret", "", "Also accept signatures made with this secret (for secret rotation)")
+	host := fs.String("host", "", "Interface to listen on (default: all interfaces)")
+	port := fs.Int("port", 3000, "Port to listen on")
+	path := fs.String("path", "/webhook", "URL path that receives webhooks")
+	file := fs.String("file", "", "Append verified payloads to a JSONL file")
+	count := fs.Int("count", 0, "Exit after receiving this many payloads (0 runs until interrupted)")
+	signature := fs.String("signature", internal.SignatureSchemeBody, "Signature scheme the sender uses: body or timestamped")
+	profile := fs.String("profile", internal.HeaderProfileCertWatch, "Signature headers to verify: certwatch, standard-webhooks, or both")
+	tolerance := fs.Duration("tolerance", 5*time.Minute, "Maximum age of timestamped signatures (0 disables the check)")
+	verbose := fs.Bool("verbose", false, "Print full JSON payload for each request")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "CertWatch Webhook CLI v%s -- listen\n\n", version)
+		fmt.Fprintf(os.Stderr, "Receives webhook deliveries and verifies their signatures. Exits\n")
+		fmt.Fprintf(os.Stderr, "non-zero if any delivery fails verification.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret <secret>\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret <secret> -port 8080 -file received.jsonl\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret <secret> -count 10\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret <secret> -signature timestamped -tolerance 1m\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret whsec_<base64> -profile standard-webhooks\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli listen -secret <old> -next-secret <new>\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	if *secret == "" {
+		fmt.Fprintln(os.Stderr, "Error: -secret is required")
+		fmt.Fprintln(os.Stderr)
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := validateListenPath(*path); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
+	if err := validateSignatureScheme(*signature); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+	if err := validateProfile(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
+	opts := internal.ListenOptions{
+		Secret:  *secret,
+		Host:    *host,
+		Port:    *port,
+		Path:    *path,
+		File:    *file,
+		Count:   *count,
+		Verbose: *verbose,
+		NoColor: *noColor,
+
+		NextSecret:      *nextSecret,
+		SignatureScheme: *signature,
+		Profile:         *profile,
+		Tolerance:       *tolerance,
+	}
+
+	if err := internal.Listen(opts, version); err != nil {
+		internal.PrintError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateListenPath checks the value of a -path flag. The path is
+// registered as an http.ServeMux pattern, so it must be a literal path:
+// wildcards, spaces, and escapes would change or break the pattern.
+func validateListenPath(path string) error {
+	if path == "" || path[0] != '/' || path == "/health" {
+		return errors.New("-path must start with / and must not be /health")
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Path != path || u.RawQuery != "" || u.Fragment != "" ||
+		strings.ContainsAny(path, "{}") || strings.ContainsFunc(path, unicode.IsSpace) {
+		return fmt.Errorf("-path %q must be a literal URL path, without wildcards, spaces, escapes, a query, or a fragment", path)
+	}
+	return nil
+}
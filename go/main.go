@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/certwatch-app/certwatch-webhook-cli/go/internal"
 )
@@ -12,15 +13,41 @@ import (
 var version = "dev"
 
 func main() {
-	url := flag.String("url", "", "Target URL to deliver webhook payloads to")
+	// Subcommands have their own flag sets.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "listen":
+			runListen(os.Args[2:])
+			return
+		case "mock-server":
+			runMockServer(os.Args[2:])
+			return
+		}
+	}
+
+	var urls stringList
+	flag.Var(&urls, "url", "Target URL to deliver webhook payloads to (repeatable)")
+	targetsFile := flag.String("targets", "", "JSON file of named targets to deliver to, alongside any -url")
 	secret := flag.String("secret", "", "Webhook signing secret (for direct secret mode)")
 	apiKey := flag.String("api-key", "", "CertWatch API key (creates a test session automatically)")
 	file := flag.String("file", "", "Save payloads to a JSONL file (one JSON per line)")
+	var filters stringList
+	flag.Var(&filters, "filter", "Only write and deliver payloads matching this expression, e.g. domain=*.example.com, issuer=*Encrypt*, wildcard, validity<=90d (repeatable; all must match)")
 	raw := flag.Bool("raw", false, "Print raw NDJSON to stdout (pipe-friendly)")
 	preview := flag.Bool("preview", false, "Show a sample payload and exit (no session needed)")
 	verbose := flag.Bool("verbose", false, "Print full JSON payload for each delivery")
 	noColor := flag.Bool("no-color", false, "Disable colored output")
 	apiEndpoint := flag.String("api-endpoint", "https://api.certwatch.app", "CertWatch API endpoint")
+	report := flag.String("report", "", "Write a JSON run report to this file")
+	junit := flag.String("junit", "", "Write a JUnit XML report to this file")
+	maxReconnects := flag.Int("max-reconnects", 10, "Consecutive stream reconnect attempts before giving up, including after the server closes the stream without a complete event (0 disables)")
+	reconnectDelay := flag.Duration("reconnect-delay", time.Second, "Initial stream reconnect delay (the server's retry: value takes precedence)")
+	apiProxy := flag.String("api-proxy", "", "Proxy for the CertWatch API and stream: http://, https://, or socks5:// URL, or 'direct' (default: HTTP_PROXY/HTTPS_PROXY)")
+	apiNoProxy := flag.String("api-no-proxy", "", "Comma-separated hosts, domains, IPs, and CIDRs reached without -api-proxy")
+	delivery := addDeliveryFlags(flag.CommandLine)
 	showVersion := flag.Bool("version", false, "Print version and exit")
 
 	flag.Usage = func() {
@@ -33,7 +60,13 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -file payloads.jsonl -secret <secret>\n")
 		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -raw -secret <secret> | jq .\n")
 		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -preview\n")
-		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -url <target> -file out.jsonl -secret <secret>\n\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -url <target> -file out.jsonl -secret <secret>\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -targets targets.json -secret <secret> -max-attempts 5 -report report.json\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -url <target> -secret <secret> -expect-status 202 -chaos wrong-signature=0.1\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  replay       Re-deliver payloads from a JSONL file written by -file\n")
+		fmt.Fprintf(os.Stderr, "  listen       Run a webhook receiver that verifies signatures\n")
+		fmt.Fprintf(os.Stderr, "  mock-server  Serve a local mock of the CertWatch API for offline runs\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
@@ -45,12 +78,19 @@ func main() {
 		os.Exit(0)
 	}
 
-	// --preview mode: skip all validation, just show sample and exit.
+	// --preview mode: skip stream validation, just show sample and exit.
 	if *preview {
+		deliveryOpts, err := delivery.options()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+			os.Exit(1)
+		}
 		opts := internal.CliOptions{
 			Secret:  *secret,
 			Preview: true,
 			NoColor: *noColor,
+
+			DeliveryOptions: deliveryOpts,
 		}
 		if err := internal.Run(opts, version); err != nil {
 			internal.PrintError(err.Error())
@@ -59,14 +99,41 @@ func main() {
 		os.Exit(0)
 	}
 
+	targets, err := resolveTargets(urls, *targetsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
 	// For stream modes, require at least one output target.
-	if *url == "" && *file == "" && !*raw {
-		fmt.Fprintln(os.Stderr, "Error: at least one of -url, -file, or -raw is required")
+	if len(targets) == 0 && *file == "" && !*raw {
+		fmt.Fprintln(os.Stderr, "Error: at least one of -url, -targets, -file, or -raw is required")
 		fmt.Fprintln(os.Stderr)
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	deliveryOpts, err := delivery.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
+	filter, err := internal.ParsePayloadFilter(filters)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -filter: "+err.Error())
+		os.Exit(1)
+	}
+
+	apiProxyOpts := internal.ProxyOptions{URL: *apiProxy, NoProxy: *apiNoProxy}
+	if apiProxyOpts.URL, err = internal.ExpandEnv(apiProxyOpts.URL); err == nil {
+		err = internal.ValidateProxy(apiProxyOpts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -api-proxy: "+err.Error())
+		os.Exit(1)
+	}
+
 	// Require authentication for stream modes.
 	if *apiKey == "" && *secret == "" {
 		fmt.Fprintln(os.Stderr, "Error: either -api-key or -secret is required")
@@ -76,7 +143,7 @@ func main() {
 	}
 
 	opts := internal.CliOptions{
-		URL:         *url,
+		Targets:     targets,
 		Secret:      *secret,
 		APIKey:      *apiKey,
 		File:        *file,
@@ -85,6 +152,15 @@ func main() {
 		Verbose:     *verbose,
 		NoColor:     *noColor,
 		APIEndpoint: *apiEndpoint,
+		Report:      *report,
+		JUnit:       *junit,
+		Filter:      filter,
+
+		MaxReconnects:  *maxReconnects,
+		ReconnectDelay: *reconnectDelay,
+		APIProxy:       apiProxyOpts,
+
+		DeliveryOptions: deliveryOpts,
 	}
 
 	if err := internal.Run(opts, version); err != nil {
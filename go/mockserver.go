@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/certwatch-app/certwatch-webhook-cli/go/internal"
+)
+
+// runMockServer implements the "mock-server" command, a local stand-in for
+// the CertWatch API.
+func runMockServer(args []string) {
+	fs := flag.NewFlagSet("mock-server", flag.ExitOnError)
+
+	host := fs.String("host", "", "Interface to listen on (default: all interfaces)")
+	port := fs.Int("port", 8787, "Port to listen on")
+	fixture := fs.String("fixture", "", "JSONL file of payloads to stream (default: generated samples)")
+	rate := fs.Float64("rate", 2, "Payloads per second")
+	duration := fs.Duration("duration", 60*time.Second, "Stream duration before the complete event")
+	secret := fs.String("secret", "", "Require this stream secret (default: accept any secret)")
+	errorAfter := fs.Int("error-after", 0, "Send an error event after this many payloads (0 disables)")
+	dropAfter := fs.Int("drop-after", 0, "Abort each stream connection after this many payloads (0 disables)")
+	retry := fs.Duration("retry", 0, "Reconnection time to send in a retry: field (0 omits it)")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "CertWatch Webhook CLI v%s -- mock-server\n\n", version)
+		fmt.Fprintf(os.Stderr, "Serves the CertWatch session and SSE stream endpoints locally for\n")
+		fmt.Fprintf(os.Stderr, "hermetic end-to-end tests.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli mock-server -port 8787\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli mock-server -fixture payloads.jsonl -rate 20 -duration 10s\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli -api-endpoint http://localhost:8787 -secret test -url <target>\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	// Payloads are sent on a ticker, whose interval must be at least 1ns.
+	if !(*rate > 0) || *rate > float64(time.Second) {
+		fmt.Fprintln(os.Stderr, "Error: -rate must be greater than 0 and at most 1000000000")
+		os.Exit(1)
+	}
+	if *duration <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -duration must be greater than 0")
+		os.Exit(1)
+	}
+
+	opts := internal.MockServerOptions{
+		Host:       *host,
+		Port:       *port,
+		Fixture:    *fixture,
+		Rate:       *rate,
+		Duration:   *duration,
+		Secret:     *secret,
+		ErrorAfter: *errorAfter,
+		DropAfter:  *dropAfter,
+		Retry:      *retry,
+		NoColor:    *noColor,
+	}
+
+	if err := internal.MockServer(opts, version); err != nil {
+		internal.PrintError(err.Error())
+		os.Exit(1)
+	}
+}
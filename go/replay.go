@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/certwatch-app/certwatch-webhook-cli/go/internal"
+)
+
+// runReplay implements the "replay" command, which re-delivers payloads from
+// a JSONL file written by -file.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+
+	file := fs.String("file", "", "JSONL file of payloads to replay (as written by -file)")
+	var urls stringList
+	fs.Var(&urls, "url", "Target URL to deliver webhook payloads to (repeatable)")
+	targetsFile := fs.String("targets", "", "JSON file of named targets to deliver to, alongside any -url")
+	secret := fs.String("secret", "", "Webhook signing secret used to re-sign each payload")
+	timing := fs.String("timing", internal.ReplayTimingFast, "Replay timing: original, rate, or fast")
+	rate := fs.Float64("rate", 10, "Payloads per second when -timing is rate")
+	var filters stringList
+	fs.Var(&filters, "filter", "Only deliver payloads matching this expression, e.g. domain=*.example.com, wildcard, validity<=90d (repeatable; all must match)")
+	report := fs.String("report", "", "Write a JSON run report to this file")
+	junit := fs.String("junit", "", "Write a JUnit XML report to this file")
+	verbose := fs.Bool("verbose", false, "Print full JSON payload for each delivery")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	delivery := addDeliveryFlags(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "CertWatch Webhook CLI v%s -- replay\n\n", version)
+		fmt.Fprintf(os.Stderr, "Re-signs and re-delivers payloads captured with -file, entirely offline.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli replay -file payloads.jsonl -url <target> -secret <secret>\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli replay -file payloads.jsonl -url <target> -secret <secret> -timing original\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli replay -file payloads.jsonl -url <target> -secret <secret> -timing rate -rate 50\n")
+		fmt.Fprintf(os.Stderr, "  certwatch-webhook-cli replay -file payloads.jsonl -targets targets.json -secret <secret> -filter 'domain=*.example.com'\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	targets, err := resolveTargets(urls, *targetsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
+	if *file == "" || len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -file and at least one -url or -targets entry are required")
+		fmt.Fprintln(os.Stderr)
+		fs.Usage()
+		os.Exit(1)
+	}
+	for _, t := range targets {
+		if *secret == "" && t.Secret == "" {
+			fmt.Fprintf(os.Stderr, "Error: -secret is required (target %s has no secret of its own)\n", t.Name)
+			os.Exit(1)
+		}
+	}
+
+	switch *timing {
+	case internal.ReplayTimingOriginal, internal.ReplayTimingFast:
+	case internal.ReplayTimingRate:
+		if *rate <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -rate must be greater than 0")
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown -timing %q (want original, rate, or fast)\n", *timing)
+		os.Exit(1)
+	}
+
+	filter, err := internal.ParsePayloadFilter(filters)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -filter: "+err.Error())
+		os.Exit(1)
+	}
+
+	deliveryOpts, err := delivery.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+
+	opts := internal.ReplayOptions{
+		File:    *file,
+		Targets: targets,
+		Secret:  *secret,
+		Timing:  *timing,
+		Rate:    *rate,
+		Filter:  filter,
+		Report:  *report,
+		JUnit:   *junit,
+		Verbose: *verbose,
+		NoColor: *noColor,
+
+		DeliveryOptions: deliveryOpts,
+	}
+
+	if err := internal.Replay(opts, version); err != nil {
+		internal.PrintError(err.Error())
+		os.Exit(1)
+	}
+}